@@ -0,0 +1,133 @@
+package wemvc
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// ActionParams the controller that declares the parameter names of the action methods.
+// The key of the map is the name of the action method, and the value is the parameter names in order.
+// For example:
+//
+//	func (c UserController) ActionParams() map[string][]string {
+//		return map[string][]string{"GetUser": {"id", "verbose"}}
+//	}
+//
+//	func (c UserController) GetUser(id int, verbose bool) interface{} { ... }
+//
+// The parameters are bound by name from the route data, query string and form values.
+// The struct parameters are bound by ModelParse, so the name of them can be empty.
+// The controller panics when the route is registered if the method has parameters and the names are not declared
+type ActionParams interface {
+	ActionParams() map[string][]string
+}
+
+const actionParamsMethod = "ActionParams"
+
+// actionParam the parameter of the action method
+type actionParam struct {
+	name    string
+	typ     reflect.Type
+	isModel bool
+}
+
+func isModelType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// newActionParams get the parameters of the action method. The errActionParamsUndeclared is returned if the method
+// has parameters and the names are not declared by the ActionParams of the controller, and the errActionParamNames is
+// returned if the names do not match the parameters. The helper methods of the embedded Controller are not actions
+func newActionParams(ctrlType reflect.Type, method reflect.Method, names []string) ([]*actionParam, bool, error) {
+	var numIn = method.Type.NumIn() - 1
+	if numIn == 0 {
+		return nil, true, nil
+	}
+	if names == nil {
+		if _, ok := reflect.TypeOf(&Controller{}).MethodByName(method.Name); ok {
+			return nil, false, nil
+		}
+		return nil, false, errActionParamsUndeclared(ctrlType.String(), method.Name)
+	}
+	if len(names) != numIn {
+		return nil, false, errActionParamNames(ctrlType.String(), method.Name)
+	}
+	var params = make([]*actionParam, 0, numIn)
+	for i := 0; i < numIn; i++ {
+		var param = &actionParam{name: names[i], typ: method.Type.In(i + 1)}
+		if isModelType(param.typ) {
+			param.isModel = true
+		} else if !isScalarKind(param.typ.Kind()) || len(param.name) == 0 {
+			return nil, false, errActionParamNames(ctrlType.String(), method.Name)
+		}
+		params = append(params, param)
+	}
+	return params, true, nil
+}
+
+// actionValues get the values that are used to bind the action parameters.
+// The route data takes precedence over the query string, and the query string takes precedence over the form values
+func (ctx *Context) actionValues() url.Values {
+	var values = make(url.Values)
+	for key, v := range ctx.req.PostForm {
+		values[key] = v
+	}
+	for key, v := range ctx.req.URL.Query() {
+		values[key] = v
+	}
+	if ctx.Route != nil {
+		for key, v := range ctx.Route.RouteData {
+			values.Set(key, v)
+		}
+	}
+	return values
+}
+
+// bindActionParams convert the request values to the action parameters
+func (ctx *Context) bindActionParams(params []*actionParam) ([]reflect.Value, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	var values = ctx.actionValues()
+	var args = make([]reflect.Value, 0, len(params))
+	for _, param := range params {
+		if param.isModel {
+			var model reflect.Value
+			if param.typ.Kind() == reflect.Ptr {
+				model = reflect.New(param.typ.Elem())
+			} else {
+				model = reflect.New(param.typ)
+			}
+			if err := modelParse(model.Interface(), values); err != nil {
+				return nil, errActionParam(param.name, err)
+			}
+			if param.typ.Kind() != reflect.Ptr {
+				model = model.Elem()
+			}
+			args = append(args, model)
+			continue
+		}
+		var arg = reflect.New(param.typ).Elem()
+		if v, ok := values[param.name]; ok && len(v) > 0 {
+			if err := setFieldValue(param.typ.Kind(), arg, v[0]); err != nil {
+				return nil, errActionParam(param.name, err)
+			}
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
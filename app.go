@@ -1,231 +1,278 @@
 package wemvc
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-	"runtime"
-	"strings"
 )
 
 // CtxHandler the error handler define
 type ErrorHandler func(*http.Request) *ContentResult
 
+// Default get the default application that is used by the package level functions
+func Default() *Application {
+	return defaultApp
+}
+
 // RootDir get the root file path of the web server
 func RootDir() string {
-	return app.webRoot
+	return defaultApp.RootDir()
 }
 
 // Config get the config data
 func Config() Configuration {
-	return app.config
+	return defaultApp.Config()
 }
 
 // Cache get the cache manager
 func Cache() *CacheManager {
-	return app.cacheManager
+	return defaultApp.Cache()
 }
 
 // Watcher get the file watcher
 func Watcher() *FileWatcher {
-	return app.fileWatcher
+	return defaultApp.Watcher()
 }
 
+// UseFriendlyAction use the friendly action name like 'get-user-info' instead of 'getuserinfo'
 func UseFriendlyAction() {
-	app.friendlyActionName = true
+	defaultApp.UseFriendlyAction()
+}
+
+// UseETag generate the weak ETag of the content results that are returned by the controllers
+func UseETag() {
+	defaultApp.UseETag()
 }
 
 // MapPath Returns the physical file path that corresponds to the specified virtual path.
 // @param virtualPath: the virtual path starts with
 // @return the absolute file path
 func MapPath(virtualPath string) string {
-	return app.mapPath(virtualPath)
+	return defaultApp.MapPath(virtualPath)
 }
 
 // Namespace get the namespace by name
 func Namespace(ns string) *NsSection {
-	return app.getNamespace(ns)
+	return defaultApp.Namespace(ns)
 }
 
 // AddViewFunc add the view func to the view func map
 func AddViewFunc(name string, f interface{}) {
-	app.assertNotLocked()
-	app.addViewFunc(name, f)
+	defaultApp.AddViewFunc(name, f)
 }
 
 // AddRouteFunc add the route analyze helper function to server
 func AddRouteFunc(name string, f RouteValidateFunc) {
-	app.assertNotLocked()
-	err := app.routing.addFunc(name, f)
-	if err != nil {
-		panic(err)
-	}
+	defaultApp.AddRouteFunc(name, f)
 }
 
 // SetDomain set the server domain
 func SetDomain(domain string) {
-	app.domain = domain
+	defaultApp.SetDomain(domain)
 }
 
 // GetDomain get the server domain
 func GetDomain() string {
-	return app.domain
+	return defaultApp.GetDomain()
 }
 
 // SetRootDir set the webroot of the web application
 func SetRootDir(rootDir string) {
-	app.assertNotLocked()
-	if !IsDir(rootDir) {
-		panic(errInvalidRoot)
-	}
-	app.webRoot = rootDir
+	defaultApp.SetRootDir(rootDir)
 }
 
 // SetViewExt set the view file extension
 func SetViewExt(ext string) {
-	app.assertNotLocked()
-	if len(ext) < 1 || !strings.HasPrefix(ext, ".") {
-		return
-	}
-	if runtime.GOOS == "windows" {
-		app.viewExt = strings.ToLower(ext)
-	} else {
-		app.viewExt = ext
-	}
-	if app.namespaces != nil {
-		for _, ns := range app.namespaces {
-			ns.viewExt = ext
-		}
-	}
+	defaultApp.SetViewExt(ext)
 }
 
 // SetPathFilter set the route path filter
 func SetPathFilter(pathPrefix string, filterFunc CtxFilter) {
-	app.assertNotLocked()
-	if !app.routing.MatchCase {
-		pathPrefix = strings.ToLower(pathPrefix)
-	}
-	app.addFilter(pathPrefix, filterFunc)
+	defaultApp.SetPathFilter(pathPrefix, filterFunc)
+}
+
+// SetSlashPolicy set the policy of the request url with trailing slash or empty path parts
+func SetSlashPolicy(p URLPolicy) {
+	defaultApp.SetSlashPolicy(p)
+}
+
+// SetCasePolicy set the policy of the request url that the letter case is different from the route or the static path
+func SetCasePolicy(p URLPolicy) {
+	defaultApp.SetCasePolicy(p)
 }
 
 // StaticDir set the path as a static path that the file under this path is served as static file
 // @param pathPrefix: the path prefix starts with '/'
 func StaticDir(pathPrefix string) {
-	app.staticDir(pathPrefix)
+	defaultApp.StaticDir(pathPrefix)
 }
 
 // StaticFile serve the path as static file
 func StaticFile(path string) {
-	app.staticFile(path)
+	defaultApp.StaticFile(path)
 }
 
 // HandleError handle the error code with the error handler
 func HandleError(errorCode int, handler ErrorHandler) {
-	app.assertNotLocked()
-	app.errorHandlers[errorCode] = handler
+	defaultApp.HandleError(errorCode, handler)
 }
 
 // OnAppInit add app init handler
 func OnAppInit(h EventHandler) {
-	app.onAppInit(h)
+	defaultApp.OnAppInit(h)
+}
+
+// OnAppShutdown add app shutdown handler
+func OnAppShutdown(h EventHandler) {
+	defaultApp.OnAppShutdown(h)
 }
 
 // BeforeCheck register context filter to before security check step
 func BeforeCheck(filterFunc CtxFilter) {
-	app.regRequestFilter(beforeCheck, filterFunc)
+	defaultApp.BeforeCheck(filterFunc)
 }
 
 // AfterCheck register context filter to after security check step
 func AfterCheck(filterFunc CtxFilter) {
-	app.regRequestFilter(afterCheck, filterFunc)
+	defaultApp.AfterCheck(filterFunc)
 }
 
 // BeforeServeStatic register context filter to before serving static file step
 func BeforeServeStatic(filterFunc CtxFilter) {
-	app.regRequestFilter(beforeStatic, filterFunc)
+	defaultApp.BeforeServeStatic(filterFunc)
 }
 
 // AfterServeStatic register context filter to after serving static file step
 func AfterServeStatic(filterFunc CtxFilter) {
-	app.regRequestFilter(afterStatic, filterFunc)
+	defaultApp.AfterServeStatic(filterFunc)
 }
 
 // BeforeRoute register context filter to before routing step
 func BeforeRoute(filterFunc CtxFilter) {
-	app.regRequestFilter(beforeRoute, filterFunc)
+	defaultApp.BeforeRoute(filterFunc)
 }
 
 // AfterRoute register context filter to after routing step
 func AfterRoute(filterFunc CtxFilter) {
-	app.regRequestFilter(afterRoute, filterFunc)
+	defaultApp.AfterRoute(filterFunc)
 }
 
 // BeforeExecAction register context filter to before executing action step
 func BeforeExecAction(filterFunc CtxFilter) {
-	app.regRequestFilter(beforeAction, filterFunc)
+	defaultApp.BeforeExecAction(filterFunc)
 }
 
 // AfterExecAction register context filter to after executing action step
 func AfterExecAction(filterFunc CtxFilter) {
-	app.regRequestFilter(afterAction, filterFunc)
+	defaultApp.AfterExecAction(filterFunc)
 }
 
 // Route set the route rule
 func Route(routePath string, c interface{}, defaultAction ...string) {
-	app.assertNotLocked()
-	action := "index"
-	if len(defaultAction) > 0 && len(defaultAction[0]) > 0 {
-		action = defaultAction[0]
-	}
-	app.addRoute("", routePath, c, action)
+	defaultApp.Route(routePath, c, defaultAction...)
+}
+
+// RouteNamed set the route rule with a name
+func RouteNamed(name, routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RouteNamed(name, routePath, c, defaultAction...)
+}
+
+// URLFor generate the URL of the named route with the route params
+func URLFor(name string, params map[string]string) (string, error) {
+	return defaultApp.URLFor(name, params)
+}
+
+// RouteMethods set the route rule that only accepts the http methods
+func RouteMethods(routePath string, methods []string, c interface{}, defaultAction ...string) {
+	defaultApp.RouteMethods(routePath, methods, c, defaultAction...)
+}
+
+// RouteGet set the route rule that only accepts the GET (and HEAD) requests
+func RouteGet(routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RouteGet(routePath, c, defaultAction...)
+}
+
+// RoutePost set the route rule that only accepts the POST requests
+func RoutePost(routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RoutePost(routePath, c, defaultAction...)
+}
+
+// RoutePut set the route rule that only accepts the PUT requests
+func RoutePut(routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RoutePut(routePath, c, defaultAction...)
+}
+
+// RouteDelete set the route rule that only accepts the DELETE requests
+func RouteDelete(routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RouteDelete(routePath, c, defaultAction...)
+}
+
+// RoutePatch set the route rule that only accepts the PATCH requests
+func RoutePatch(routePath string, c interface{}, defaultAction ...string) {
+	defaultApp.RoutePatch(routePath, c, defaultAction...)
 }
 
 // PrintRouteInfo print route tree information
 func PrintRouteInfo() []byte {
-	return data2Json(app.routing)
+	return defaultApp.PrintRouteInfo()
+}
+
+// Routes get the route table of the web application
+func Routes() (RouteTable, error) {
+	return defaultApp.Routes()
 }
 
 // RenderView render the view template and get the result
 func RenderView(viewName string, data interface{}) ([]byte, error) {
-	return app.renderView(viewName, data)
+	return defaultApp.RenderView(viewName, data)
+}
+
+// RegFormatter register the formatter of the content type for the content negotiation
+func RegFormatter(contentType string, f Formatter) {
+	defaultApp.RegFormatter(contentType, f)
 }
 
 // RegSessionProvider register session provider
 func RegSessionProvider(name string, provider SessionProvider) {
-	app.regSessionProvider(name, provider)
+	defaultApp.RegSessionProvider(name, provider)
+}
+
+// RegSessionCodec register the session codec
+func RegSessionCodec(name string, codec SessionCodec) {
+	defaultApp.RegSessionCodec(name, codec)
+}
+
+// Init initialize the web application and get the http handler
+func Init() (http.Handler, error) {
+	return defaultApp.Init()
 }
 
 // Run run the web application
-func Run(port int) {
-	err := app.init()
-	if err != nil {
-		panic(err)
-	}
-	app.locked = true
-	app.port = port
-	portStr := fmt.Sprintf("%s:%d", app.domain, app.port)
-	err = http.ListenAndServe(portStr, app)
-	if err != nil {
-		panic(err)
-	}
+func Run(port int) error {
+	return defaultApp.Run(port)
 }
 
 // RunTLS run the web application as TLS
-func RunTLS(port int, certFile, keyFile string) {
-	err := app.init()
-	if err != nil {
-		panic(err)
-	}
-	app.locked = true
-	app.port = port
-	portStr := fmt.Sprintf("%s:%d", app.domain, app.port)
-	err = http.ListenAndServeTLS(portStr, certFile, keyFile, app)
-	if err != nil {
-		panic(err)
-	}
-}
-
-var app *server
+func RunTLS(port int, certFile, keyFile string) error {
+	return defaultApp.RunTLS(port, certFile, keyFile)
+}
+
+// RunContext run the web application on the address until the ctx is done
+func RunContext(ctx context.Context, addr string) error {
+	return defaultApp.RunContext(ctx, addr)
+}
+
+// RunTLSContext run the web application as TLS on the address until the ctx is done
+func RunTLSContext(ctx context.Context, addr, certFile, keyFile string) error {
+	return defaultApp.RunTLSContext(ctx, addr, certFile, keyFile)
+}
+
+// Shutdown shut down the web application gracefully
+func Shutdown(ctx context.Context) error {
+	return defaultApp.Shutdown(ctx)
+}
+
+var defaultApp *Application
 
 func init() {
-	app = newServer(WorkingDir())
+	defaultApp = newServer(WorkingDir())
 }
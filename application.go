@@ -0,0 +1,341 @@
+package wemvc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// New create a new web application that serves the files under rootDir
+func New(rootDir string) *Application {
+	if !IsDir(rootDir) {
+		panic(errInvalidRoot)
+	}
+	return newServer(rootDir)
+}
+
+// RootDir get the root file path of the web application
+func (app *Application) RootDir() string {
+	return app.webRoot
+}
+
+// Config get the config data
+func (app *Application) Config() Configuration {
+	return app.config
+}
+
+// Cache get the cache manager
+func (app *Application) Cache() *CacheManager {
+	return app.cacheManager
+}
+
+// Watcher get the file watcher
+func (app *Application) Watcher() *FileWatcher {
+	return app.fileWatcher
+}
+
+// UseFriendlyAction use the friendly action name like 'get-user-info' instead of 'getuserinfo'
+func (app *Application) UseFriendlyAction() {
+	app.assertNotLocked()
+	app.routing.friendlyAction = true
+}
+
+// UseETag generate the weak ETag of the content results that are returned by the controllers, so the 304 response
+// is returned if the content is not modified. It can also be enabled by the 'etag' attribute of the server config
+func (app *Application) UseETag() {
+	app.assertNotLocked()
+	app.autoETag = true
+}
+
+// MapPath Returns the physical file path that corresponds to the specified virtual path.
+func (app *Application) MapPath(virtualPath string) string {
+	return app.mapPath(virtualPath)
+}
+
+// Namespace get the namespace by name
+func (app *Application) Namespace(ns string) *NsSection {
+	return app.getNamespace(ns)
+}
+
+// AddViewFunc add the view func to the view func map
+func (app *Application) AddViewFunc(name string, f interface{}) {
+	app.assertNotLocked()
+	app.addViewFunc(name, f)
+}
+
+// AddRouteFunc add the route analyze helper function to the application
+func (app *Application) AddRouteFunc(name string, f RouteValidateFunc) {
+	app.assertNotLocked()
+	err := app.routing.addFunc(name, f)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// SetDomain set the server domain
+func (app *Application) SetDomain(domain string) {
+	app.domain = domain
+}
+
+// GetDomain get the server domain
+func (app *Application) GetDomain() string {
+	return app.domain
+}
+
+// SetRootDir set the webroot of the web application
+func (app *Application) SetRootDir(rootDir string) {
+	app.assertNotLocked()
+	if !IsDir(rootDir) {
+		panic(errInvalidRoot)
+	}
+	app.webRoot = rootDir
+}
+
+// SetViewExt set the view file extension
+func (app *Application) SetViewExt(ext string) {
+	app.assertNotLocked()
+	if len(ext) < 1 || !strings.HasPrefix(ext, ".") {
+		return
+	}
+	if runtime.GOOS == "windows" {
+		app.viewExt = strings.ToLower(ext)
+	} else {
+		app.viewExt = ext
+	}
+	if app.namespaces != nil {
+		for _, ns := range app.namespaces {
+			ns.viewExt = ext
+		}
+	}
+}
+
+// SetPathFilter set the route path filter
+func (app *Application) SetPathFilter(pathPrefix string, filterFunc CtxFilter) {
+	app.assertNotLocked()
+	app.addFilter(pathPrefix, filterFunc)
+}
+
+// SetSlashPolicy set the policy of the request url with trailing slash or empty path parts. The default policy is PolicyLenient
+func (app *Application) SetSlashPolicy(p URLPolicy) {
+	app.assertNotLocked()
+	if !p.valid() {
+		panic(errInvalidURLPolicy(p.String()))
+	}
+	app.slashPolicy = p
+}
+
+// SetCasePolicy set the policy of the request url that the letter case is different from the route or the static path.
+// The default policy is PolicyStrict, or PolicyLenient on windows
+func (app *Application) SetCasePolicy(p URLPolicy) {
+	app.assertNotLocked()
+	app.setCasePolicy(p)
+}
+
+// StaticDir set the path as a static path that the file under this path is served as static file
+// @param pathPrefix: the path prefix starts with '/'
+func (app *Application) StaticDir(pathPrefix string) {
+	app.staticDir(pathPrefix)
+}
+
+// StaticFile serve the path as static file
+func (app *Application) StaticFile(path string) {
+	app.staticFile(path)
+}
+
+// HandleError handle the error code with the error handler
+func (app *Application) HandleError(errorCode int, handler ErrorHandler) {
+	app.assertNotLocked()
+	app.errorHandlers[errorCode] = handler
+}
+
+// OnAppInit add app init handler
+func (app *Application) OnAppInit(h EventHandler) {
+	app.onAppInit(h)
+}
+
+// OnAppShutdown add app shutdown handler. The shutdown handlers are executed in the reverse order of the registration
+func (app *Application) OnAppShutdown(h EventHandler) {
+	app.onAppShutdown(h)
+}
+
+// BeforeCheck register context filter to before security check step
+func (app *Application) BeforeCheck(filterFunc CtxFilter) {
+	app.regRequestFilter(beforeCheck, filterFunc)
+}
+
+// AfterCheck register context filter to after security check step
+func (app *Application) AfterCheck(filterFunc CtxFilter) {
+	app.regRequestFilter(afterCheck, filterFunc)
+}
+
+// BeforeServeStatic register context filter to before serving static file step
+func (app *Application) BeforeServeStatic(filterFunc CtxFilter) {
+	app.regRequestFilter(beforeStatic, filterFunc)
+}
+
+// AfterServeStatic register context filter to after serving static file step
+func (app *Application) AfterServeStatic(filterFunc CtxFilter) {
+	app.regRequestFilter(afterStatic, filterFunc)
+}
+
+// BeforeRoute register context filter to before routing step
+func (app *Application) BeforeRoute(filterFunc CtxFilter) {
+	app.regRequestFilter(beforeRoute, filterFunc)
+}
+
+// AfterRoute register context filter to after routing step
+func (app *Application) AfterRoute(filterFunc CtxFilter) {
+	app.regRequestFilter(afterRoute, filterFunc)
+}
+
+// BeforeExecAction register context filter to before executing action step
+func (app *Application) BeforeExecAction(filterFunc CtxFilter) {
+	app.regRequestFilter(beforeAction, filterFunc)
+}
+
+// AfterExecAction register context filter to after executing action step
+func (app *Application) AfterExecAction(filterFunc CtxFilter) {
+	app.regRequestFilter(afterAction, filterFunc)
+}
+
+// Route set the route rule
+func (app *Application) Route(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, nil, c, defaultAction...)
+}
+
+// RouteMethods set the route rule that only accepts the http methods.
+// The request with other methods gets the '405 Method Not Allowed' response
+func (app *Application) RouteMethods(routePath string, methods []string, c interface{}, defaultAction ...string) {
+	app.assertNotLocked()
+	action := "index"
+	if len(defaultAction) > 0 && len(defaultAction[0]) > 0 {
+		action = defaultAction[0]
+	}
+	app.addRoute("", "", routePath, c, action, methods)
+}
+
+// RouteNamed set the route rule with a name, the URL of the route can be generated by URLFor
+func (app *Application) RouteNamed(name, routePath string, c interface{}, defaultAction ...string) {
+	app.assertNotLocked()
+	if len(name) == 0 {
+		panic(errRouteNameEmpty)
+	}
+	action := "index"
+	if len(defaultAction) > 0 && len(defaultAction[0]) > 0 {
+		action = defaultAction[0]
+	}
+	app.addRoute(name, "", routePath, c, action, nil)
+}
+
+// URLFor generate the URL of the named route with the route params.
+// The default value is used if the route param is not set, and the params that are not used by the route are appended as query string
+func (app *Application) URLFor(name string, params map[string]string) (string, error) {
+	return app.urlFor(name, params)
+}
+
+// RouteGet set the route rule that only accepts the GET (and HEAD) requests
+func (app *Application) RouteGet(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, []string{"GET"}, c, defaultAction...)
+}
+
+// RoutePost set the route rule that only accepts the POST requests
+func (app *Application) RoutePost(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, []string{"POST"}, c, defaultAction...)
+}
+
+// RoutePut set the route rule that only accepts the PUT requests
+func (app *Application) RoutePut(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, []string{"PUT"}, c, defaultAction...)
+}
+
+// RouteDelete set the route rule that only accepts the DELETE requests
+func (app *Application) RouteDelete(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, []string{"DELETE"}, c, defaultAction...)
+}
+
+// RoutePatch set the route rule that only accepts the PATCH requests
+func (app *Application) RoutePatch(routePath string, c interface{}, defaultAction ...string) {
+	app.RouteMethods(routePath, []string{"PATCH"}, c, defaultAction...)
+}
+
+// PrintRouteInfo print route tree information
+func (app *Application) PrintRouteInfo() []byte {
+	return data2Json(app.routing)
+}
+
+// Routes get the route table that lists the pattern, namespace, controller, actions, http methods and defaults of the routes
+func (app *Application) Routes() (RouteTable, error) {
+	return app.routeTable()
+}
+
+// RenderView render the view template and get the result
+func (app *Application) RenderView(viewName string, data interface{}) ([]byte, error) {
+	return app.renderView(viewName, data)
+}
+
+// RegFormatter register the formatter of the content type like 'text/csv; charset=utf-8' for the content negotiation.
+// The formatters of JSON, XML and plain text are registered by default, and the formatter of the same media type is replaced
+func (app *Application) RegFormatter(contentType string, f Formatter) {
+	app.regFormatter(contentType, f)
+}
+
+// RegSessionProvider register session provider
+func (app *Application) RegSessionProvider(name string, provider SessionProvider) {
+	app.regSessionProvider(name, provider)
+}
+
+// RegSessionCodec register the session codec that is selected by the 'codec' attribute of the session config.
+// The 'gob' and 'json' codecs are registered by default, and the codec of the same name is replaced
+func (app *Application) RegSessionCodec(name string, codec SessionCodec) {
+	app.regSessionCodec(name, codec)
+}
+
+// Init execute the app init handlers, lock the application settings and get the http handler of the application.
+// The handler can be mounted to any http.Server, httptest.Server or http.ServeMux
+func (app *Application) Init() (http.Handler, error) {
+	if err := app.init(); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// Run run the web application
+func (app *Application) Run(port int) error {
+	app.port = port
+	return app.RunContext(context.Background(), fmt.Sprintf("%s:%d", app.domain, app.port))
+}
+
+// RunTLS run the web application as TLS
+func (app *Application) RunTLS(port int, certFile, keyFile string) error {
+	app.port = port
+	return app.RunTLSContext(context.Background(), fmt.Sprintf("%s:%d", app.domain, app.port), certFile, keyFile)
+}
+
+// RunContext run the web application on the address until the ctx is done or Shutdown is called.
+// When the ctx is done, the application is shut down gracefully.
+func (app *Application) RunContext(ctx context.Context, addr string) error {
+	if _, err := app.Init(); err != nil {
+		return err
+	}
+	srv := app.newHTTPServer(addr)
+	return app.serve(ctx, srv, srv.ListenAndServe)
+}
+
+// RunTLSContext run the web application as TLS on the address until the ctx is done or Shutdown is called.
+func (app *Application) RunTLSContext(ctx context.Context, addr, certFile, keyFile string) error {
+	if _, err := app.Init(); err != nil {
+		return err
+	}
+	srv := app.newHTTPServer(addr)
+	return app.serve(ctx, srv, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
+
+// Shutdown stop accepting new connections, wait for the active requests until the ctx is done,
+// then stop the file watcher, the session gc and the cache gc, and execute the app shutdown handlers
+func (app *Application) Shutdown(ctx context.Context) error {
+	return app.shutdown(ctx)
+}
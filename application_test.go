@@ -0,0 +1,309 @@
+package wemvc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testAboutCtrl struct {
+	Controller
+}
+
+func (t testAboutCtrl) GetAboutUs() interface{} {
+	return t.PlainText("about")
+}
+
+func Test_Application_isolated(t *testing.T) {
+	app1 := New(os.TempDir())
+	app2 := New(os.TempDir())
+	app1.UseFriendlyAction()
+	app1.Route("/<action>", testAboutCtrl{})
+	app2.Route("/<action>", testAboutCtrl{})
+	if err := app1.initRoute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := app2.initRoute(); err != nil {
+		t.Fatal(err)
+	}
+	if c, _, _ := app1.routing.lookup("/about-us", "get"); c == nil {
+		t.Error("test 1 failed")
+	}
+	if c, _, _ := app2.routing.lookup("/about-us", "get"); c != nil {
+		t.Error("test 2 failed")
+	}
+	if c, _, _ := app2.routing.lookup("/aboutus", "get"); c == nil {
+		t.Error("test 3 failed")
+	}
+	if defaultApp.routing.friendlyAction {
+		t.Error("test 4 failed")
+	}
+}
+
+func Test_Application_RunContext(t *testing.T) {
+	app := New(os.TempDir())
+	var closed bool
+	app.OnAppShutdown(func() error {
+		closed = true
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	var errChan = make(chan error, 1)
+	go func() {
+		errChan <- app.RunContext(ctx, "127.0.0.1:0")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunContext did not return after the context is done")
+	}
+	if !closed {
+		t.Error("the shutdown handler is not executed")
+	}
+	if app.cacheManager.started {
+		t.Error("the cache manager is not stopped")
+	}
+	if !app.globalSession.gcStopped {
+		t.Error("the session gc is not stopped")
+	}
+}
+
+func Test_Application_Init(t *testing.T) {
+	app := New(os.TempDir())
+	app.Route("/", testCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || string(body) != "test" {
+		t.Errorf("unexpected response: %d %s", resp.StatusCode, body)
+	}
+	if _, err = app.Init(); err != nil {
+		t.Error("the second Init call failed: ", err)
+	}
+	app.Shutdown(context.Background())
+}
+
+func Test_Application_serverTimeouts(t *testing.T) {
+	root, err := ioutil.TempDir("", "wemvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	ioutil.WriteFile(root+"/config.xml", []byte(`<configuration>
+	<server readTimeout="10" readHeaderTimeout="5" writeTimeout="20" idleTimeout="60" shutdownTimeout="15"/>
+</configuration>`), 0644)
+	app := New(root)
+	if _, err = app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	srv := app.newHTTPServer("127.0.0.1:0")
+	if srv.ReadTimeout != 10*time.Second || srv.ReadHeaderTimeout != 5*time.Second ||
+		srv.WriteTimeout != 20*time.Second || srv.IdleTimeout != 60*time.Second {
+		t.Error("test 1 failed", srv.ReadTimeout, srv.ReadHeaderTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+	if app.shutdownTimeout() != 15*time.Second {
+		t.Error("test 2 failed", app.shutdownTimeout())
+	}
+	if New(os.TempDir()).shutdownTimeout() != 30*time.Second {
+		t.Error("test 3 failed")
+	}
+}
+
+func Test_Application_methodNotAllowed(t *testing.T) {
+	app := New(os.TempDir())
+	app.RouteGet("/about", testCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/about", nil))
+	if w.Code != 405 || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("unexpected response: %d %s", w.Code, w.Header().Get("Allow"))
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/about", nil))
+	if w.Code != 200 {
+		t.Errorf("unexpected status code: %d", w.Code)
+	}
+}
+
+func Test_Application_Routes(t *testing.T) {
+	app := New(os.TempDir())
+	defer app.Shutdown(context.Background())
+	app.RouteNamed("user", "/user/<id:int>/<action=detail>", testCtrl{})
+	app.RoutePost("/about", testAboutCtrl{})
+	table, err := app.Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 2 || table[0].Name != "user" || table[0].Defaults["action"] != "detail" || table[1].Methods[0] != "POST" {
+		t.Fatal("unexpected route table: ", string(table.JSON()))
+	}
+	text := table.String()
+	if !strings.Contains(text, "/user/<id:int>/<action=detail>") || !strings.Contains(text, "wemvc.testAboutCtrl") {
+		t.Error("unexpected route table text: ", text)
+	}
+	app.Route("/user/<uid:int>/<action=detail>", testCtrl{})
+	app.Route("/user/<name>/info", testCtrl{})
+	app.Route("/user/<key:int>/info", testCtrl{})
+	if _, err = app.Init(); err == nil {
+		t.Error("the route conflict is not detected")
+	}
+}
+
+func Test_Application_urlPolicy(t *testing.T) {
+	root, err := ioutil.TempDir("", "wemvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(root+"/static", 0755)
+	ioutil.WriteFile(root+"/static/index.html", []byte("index"), 0644)
+	var apps []*Application
+	defer func() {
+		for _, app := range apps {
+			app.Shutdown(context.Background())
+		}
+	}()
+	var newApp = func(slash, letterCase URLPolicy) http.Handler {
+		app := New(root)
+		apps = append(apps, app)
+		app.SetSlashPolicy(slash)
+		app.SetCasePolicy(letterCase)
+		app.Route("/About", testCtrl{})
+		app.StaticDir("/static")
+		h, err := app.Init()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	var tests = []struct {
+		h        http.Handler
+		method   string
+		url      string
+		code     int
+		location string
+	}{
+		{newApp(PolicyRedirect, PolicyRedirect), "GET", "/About/?x=1", 301, "/About?x=1"},
+		{newApp(PolicyRedirect, PolicyRedirect), "POST", "/about", 308, "/About"},
+		{newApp(PolicyRedirect, PolicyRedirect), "GET", "/About", 200, ""},
+		{newApp(PolicyRedirect, PolicyRedirect), "GET", "/static", 301, "/static/"},
+		{newApp(PolicyRedirect, PolicyRedirect), "GET", "/Static/index.html/", 301, "/static/index.html"},
+		{newApp(PolicyStrict, PolicyStrict), "GET", "/About/", 404, ""},
+		{newApp(PolicyStrict, PolicyStrict), "GET", "/about", 404, ""},
+		{newApp(PolicyStrict, PolicyStrict), "GET", "/static", 404, ""},
+		{newApp(PolicyLenient, PolicyLenient), "GET", "/about/", 200, ""},
+		{newApp(PolicyLenient, PolicyLenient), "GET", "/STATIC", 200, ""},
+		{newApp(PolicyLenient, PolicyRedirect), "GET", "/about/", 301, "/About/"},
+	}
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		test.h.ServeHTTP(w, httptest.NewRequest(test.method, test.url, nil))
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("test %d failed: %d %s", i+1, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+type testUserModel struct {
+	ID   int    `field:"id"`
+	Name string `field:"name"`
+}
+
+type testUserCtrl struct {
+	Controller
+}
+
+func (c testUserCtrl) ActionParams() map[string][]string {
+	return map[string][]string{
+		"GetUser":  {"id", "verbose"},
+		"PostUser": {""},
+	}
+}
+
+func (c testUserCtrl) GetUser(id int, verbose bool) interface{} {
+	return fmt.Sprintf("%d %v", id, verbose)
+}
+
+func (c testUserCtrl) PostUser(user *testUserModel) interface{} {
+	return fmt.Sprintf("%d %s", user.ID, user.Name)
+}
+
+type testHelperCtrl struct {
+	Controller
+}
+
+func (c testHelperCtrl) Helper(id int) interface{} {
+	return id
+}
+
+func Test_Application_actionParams(t *testing.T) {
+	app := New(os.TempDir())
+	app.Route("/user/<id>", testUserCtrl{}, "user")
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	var tests = []struct {
+		method string
+		url    string
+		form   string
+		code   int
+		body   string
+	}{
+		{"GET", "/user/12?verbose=true", "", 200, "12 true"},
+		{"GET", "/user/12?id=13", "", 200, "12 false"},
+		{"GET", "/user/abc", "", 400, ""},
+		{"GET", "/user/12?verbose=maybe", "", 400, ""},
+		{"GET", "/user/010", "", 200, "10 false"},
+		{"GET", "/user/08", "", 200, "8 false"},
+		{"POST", "/user/12", "name=steve&id=13", 200, "12 steve"},
+	}
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(test.method, test.url, strings.NewReader(test.form))
+		if len(test.form) > 0 {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		h.ServeHTTP(w, req)
+		if w.Code != test.code || (len(test.body) > 0 && w.Body.String() != test.body) {
+			t.Errorf("test %d failed: %d %s", i+1, w.Code, w.Body.String())
+		}
+	}
+}
+
+func Test_Application_undeclaredActionParams(t *testing.T) {
+	app := New(os.TempDir())
+	app.Route("/helper", testHelperCtrl{})
+	defer func() {
+		if recover() == nil {
+			t.Error("the method with undeclared parameters should panic")
+		}
+	}()
+	app.initRoute()
+}
@@ -0,0 +1,256 @@
+package wemvc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const defaultMaxBodySize int64 = 10485760
+
+// FieldError the error of converting the request value to the field of the model
+type FieldError struct {
+	Field   string `json:"field" xml:"field,attr"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+// BindError the error of binding the request to the model.
+// The status code is 400 for the invalid request body or field values, 413 for the request body that is too large,
+// and 415 for the content type that is not supported
+type BindError struct {
+	StatusCode int           `json:"-" xml:"-"`
+	Message    string        `json:"message" xml:"message"`
+	Fields     []*FieldError `json:"fields,omitempty" xml:"fields>field,omitempty"`
+}
+
+func (err *BindError) Error() string {
+	if len(err.Fields) == 0 {
+		return err.Message
+	}
+	var msg = strAdd(err.Message, ": ")
+	for i, f := range err.Fields {
+		if i > 0 {
+			msg = strAdd(msg, "; ")
+		}
+		msg = strAdd(msg, f.Field, ": ", f.Message)
+	}
+	return msg
+}
+
+func newBindError(statusCode int, msg string) *BindError {
+	return &BindError{StatusCode: statusCode, Message: msg}
+}
+
+// limitedBody the request body that stops reading when the size of the body exceeds the limit
+type limitedBody struct {
+	io.ReadCloser
+	remain   int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remain+1 {
+		p = p[:b.remain+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remain {
+		n = int(b.remain)
+		b.remain = 0
+		b.exceeded = true
+		return n, errBodyTooLarge
+	}
+	b.remain -= int64(n)
+	return n, err
+}
+
+// maxBodySize get the size limit of the request body by the 'MaxBodySize' setting, or the 'MaxFormSize' setting
+func (app *Application) maxBodySize() int64 {
+	for _, key := range []string{"MaxBodySize", "MaxFormSize"} {
+		if setting := app.config.GetSetting(key); len(setting) > 0 {
+			if size, err := strconv.ParseInt(setting, 10, 64); err == nil && size > 0 {
+				return size
+			}
+		}
+	}
+	return defaultMaxBodySize
+}
+
+// limitBody limit the size of the request body
+func (ctx *Context) limitBody() *limitedBody {
+	if body, ok := ctx.req.Body.(*limitedBody); ok {
+		return body
+	}
+	if ctx.req.Body == nil {
+		return nil
+	}
+	var body = &limitedBody{ReadCloser: ctx.req.Body, remain: ctx.app.maxBodySize()}
+	ctx.req.Body = body
+	return body
+}
+
+// bodyError get the bind error of reading the request body
+func (ctx *Context) bodyError(err error) *BindError {
+	if body, ok := ctx.req.Body.(*limitedBody); ok && body.exceeded {
+		return newBindError(413, "The request body is too large")
+	}
+	return newBindError(400, strAdd("Invalid request body: ", err.Error()))
+}
+
+// mediaType get the media type of the request body
+func (ctx *Context) mediaType() string {
+	var contentType = ctx.req.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mediaType
+}
+
+// parseForm parse the query string and the form values of the request body. The size of the request body is only
+// limited for the form and multipart form bodies, the other bodies are not read and left to the action
+func (ctx *Context) parseForm() error {
+	if ctx.req.Form != nil {
+		return nil
+	}
+	if mediaType := ctx.mediaType(); mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		ctx.req.ParseForm()
+		return nil
+	}
+	if ctx.req.ContentLength > ctx.app.maxBodySize() {
+		return newBindError(413, "The request body is too large")
+	}
+	ctx.limitBody()
+	var err error
+	if ctx.mediaType() == "multipart/form-data" {
+		err = ctx.req.ParseMultipartForm(ctx.app.maxBodySize())
+	} else {
+		err = ctx.req.ParseForm()
+	}
+	if err != nil {
+		return ctx.bodyError(err)
+	}
+	return nil
+}
+
+// bind bind the request to the model by the content type of the request.
+// The form, multipart form, JSON and XML request bodies are supported, and the query string is used if the request has no body
+func (ctx *Context) bind(model interface{}) error {
+	var mValue = reflect.ValueOf(model)
+	if model == nil || mValue.Kind() != reflect.Ptr || mValue.Elem().Kind() != reflect.Struct {
+		return errBindModel
+	}
+	var mediaType = ctx.mediaType()
+	switch {
+	case len(mediaType) == 0, mediaType == "application/x-www-form-urlencoded", mediaType == "multipart/form-data":
+		if err := ctx.parseForm(); err != nil {
+			return err
+		}
+		return modelParse(model, ctx.req.Form)
+	case mediaType == "application/json", mediaType == "text/json", strings.HasSuffix(mediaType, "+json"):
+		return ctx.bindJSON(mValue)
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return ctx.bindXML(model)
+	}
+	return newBindError(415, strAdd("The content type '", mediaType, "' is not supported"))
+}
+
+// bindJSON decode the JSON object in the request body and set the values to the fields by the field name of the model
+func (ctx *Context) bindJSON(mValue reflect.Value) error {
+	if ctx.req.ContentLength > ctx.app.maxBodySize() {
+		return newBindError(413, "The request body is too large")
+	}
+	var body = ctx.limitBody()
+	if body == nil {
+		return nil
+	}
+	var data map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return ctx.bodyError(err)
+	}
+	var mType = mValue.Elem().Type()
+	var fields []*FieldError
+	for i := 0; i < mType.NumField(); i++ {
+		var fieldName = modelFieldName(mType.Field(i))
+		var field = mValue.Elem().Field(i)
+		if len(fieldName) == 0 || !field.CanSet() {
+			continue
+		}
+		raw, ok := data[fieldName]
+		if !ok {
+			for key, value := range data {
+				if strings.EqualFold(key, fieldName) {
+					raw, ok = value, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+			fields = append(fields, &FieldError{Field: fieldName, Message: err.Error()})
+		}
+	}
+	return newFieldErrors(fields)
+}
+
+// bindXML decode the child elements of the root element in the request body as the values of the model fields.
+// The elements are matched by the field names like the form values, so only one level of the elements is bound and
+// the 'xml' tags of the model are not used
+func (ctx *Context) bindXML(model interface{}) error {
+	if ctx.req.ContentLength > ctx.app.maxBodySize() {
+		return newBindError(413, "The request body is too large")
+	}
+	var body = ctx.limitBody()
+	if body == nil {
+		return nil
+	}
+	var values = make(url.Values)
+	var decoder = xml.NewDecoder(body)
+	var depth = 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ctx.bodyError(err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				depth++
+				continue
+			}
+			var value string
+			if err = decoder.DecodeElement(&value, &t); err != nil {
+				return ctx.bodyError(err)
+			}
+			values.Add(t.Name.Local, value)
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return modelParse(model, values)
+}
+
+func newFieldErrors(fields []*FieldError) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return &BindError{StatusCode: 400, Message: "Invalid field values", Fields: fields}
+}
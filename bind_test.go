@@ -0,0 +1,113 @@
+package wemvc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testBindModel struct {
+	ID   int      `json:"id,omitempty"`
+	Name string   `field:"name"`
+	Tags []string `json:"tags"`
+	Skip string   `json:"-"`
+}
+
+func newBindContext(t *testing.T, contentType, body string) *Context {
+	app := New(os.TempDir())
+	if _, err := app.Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { app.Shutdown(context.Background()) })
+	app.config.settingMap = map[string]string{"MaxBodySize": "64"}
+	req := httptest.NewRequest("POST", "/user?name=query", strings.NewReader(body))
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return &Context{req: req, w: httptest.NewRecorder(), app: app}
+}
+
+func Test_Context_bind(t *testing.T) {
+	var m testBindModel
+	if err := newBindContext(t, "application/json; charset=utf-8", `{"id":12,"Name":"steve","tags":["a"],"Skip":"x"}`).bind(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != 12 || m.Name != "steve" || len(m.Tags) != 1 || len(m.Skip) != 0 {
+		t.Error("test 1 failed", m)
+	}
+	m = testBindModel{}
+	if err := newBindContext(t, "text/xml", `<user><id>12</id><name>steve</name></user>`).bind(&m); err != nil || m.ID != 12 || m.Name != "steve" {
+		t.Error("test 2 failed", m, err)
+	}
+	m = testBindModel{}
+	if err := newBindContext(t, "application/x-www-form-urlencoded", `id=12`).bind(&m); err != nil || m.ID != 12 || m.Name != "query" {
+		t.Error("test 3 failed", m, err)
+	}
+	err := newBindContext(t, "application/json", `{"id":"abc"}`).bind(&m)
+	if bindErr, ok := err.(*BindError); !ok || bindErr.StatusCode != 400 || len(bindErr.Fields) != 1 || bindErr.Fields[0].Field != "id" {
+		t.Error("test 4 failed", err)
+	}
+	err = newBindContext(t, "application/x-www-form-urlencoded", `id=abc&name=steve`).bind(&m)
+	if bindErr, ok := err.(*BindError); !ok || bindErr.StatusCode != 400 || len(bindErr.Fields) != 1 {
+		t.Error("test 5 failed", err)
+	}
+	err = newBindContext(t, "application/json", `{"name":"`+strings.Repeat("a", 100)+`"}`).bind(&m)
+	if bindErr, ok := err.(*BindError); !ok || bindErr.StatusCode != 413 {
+		t.Error("test 6 failed", err)
+	}
+	err = newBindContext(t, "text/plain", `abc`).bind(&m)
+	if bindErr, ok := err.(*BindError); !ok || bindErr.StatusCode != 415 {
+		t.Error("test 7 failed", err)
+	}
+	if err = newBindContext(t, "application/json", `{}`).bind(m); err != errBindModel {
+		t.Error("test 8 failed", err)
+	}
+}
+
+type testUploadCtrl struct {
+	Controller
+}
+
+func (ctrl testUploadCtrl) PostUpload() interface{} {
+	data, err := ioutil.ReadAll(ctrl.Request().Body)
+	if err != nil {
+		return ctrl.PlainText(err.Error())
+	}
+	return ctrl.PlainText(strconv.Itoa(len(data)))
+}
+
+func Test_Context_parseForm(t *testing.T) {
+	app := New(os.TempDir())
+	app.Route("/<action>", testUploadCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	// the body that is not a form is not limited by the 'MaxBodySize' setting
+	var size = int(defaultMaxBodySize) + 1048576
+	var body = strings.Repeat("a", size)
+	for i, chunked := range []bool{false, true} {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		if chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != 200 || w.Body.String() != strconv.Itoa(size) {
+			t.Error("test", i+1, "failed", w.Code, w.Body.String())
+		}
+	}
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("name="+body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != 413 {
+		t.Error("test 3 failed", w.Code)
+	}
+}
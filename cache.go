@@ -26,6 +26,7 @@ type CacheManager struct {
 	fileWatcher *FileWatcher
 	locker      *sync.RWMutex
 	started     bool
+	stopChan    chan struct{}
 }
 
 func (c *CacheManager) fileUsage(fPath string) int {
@@ -143,13 +144,21 @@ func (c *CacheManager) Remove(name string) {
 }
 
 func (c *CacheManager) gc() {
+	var stopChan = c.stopChan
 	go func() {
-		time.Sleep(c.gcFrequency)
-		var now = time.Now()
-		for name, data := range c.dataMap {
-			if now.Before(data.expire) {
+		ticker := time.NewTicker(c.gcFrequency)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChan:
+				return
+			case now := <-ticker.C:
 				c.locker.Lock()
-				delete(c.dataMap, name)
+				for name, data := range c.dataMap {
+					if now.After(data.expire) {
+						delete(c.dataMap, name)
+					}
+				}
 				c.locker.Unlock()
 			}
 		}
@@ -161,12 +170,21 @@ func (c *CacheManager) start() {
 		return
 	}
 	c.started = true
+	c.stopChan = make(chan struct{})
 	c.fileWatcher.Start()
 	detector := newCacheDetector(c)
 	c.fileWatcher.AddHandler(detector)
 	c.gc()
 }
 
+func (c *CacheManager) stop() {
+	if !c.started {
+		return
+	}
+	c.started = false
+	close(c.stopChan)
+}
+
 func newCacheManager(fw *FileWatcher, gcFrequency time.Duration) *CacheManager {
 	return &CacheManager{
 		locker:      &sync.RWMutex{},
@@ -0,0 +1,308 @@
+package wemvc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultCompressMinSize = 1024
+
+// compressedTypes the content types that are already compressed, the content type that ends with '/' is a prefix
+var compressedTypes = []string{
+	"image/", "audio/", "video/", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-compress", "application/x-bzip2",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/x-xz", "application/zstd",
+	"application/pdf", "application/octet-stream", "application/wasm",
+}
+
+// compressibleImages the image types that are not compressed by the image format
+var compressibleImages = []string{"image/svg+xml", "image/bmp", "image/x-icon", "image/vnd.microsoft.icon"}
+
+// compressOptions the options of the response compression
+type compressOptions struct {
+	level        int
+	minSize      int
+	excludeTypes []string
+	gzipPool     sync.Pool
+	zlibPool     sync.Pool
+}
+
+func newCompressOptions(conf *CompressionConfig) (*compressOptions, error) {
+	var opts = &compressOptions{level: conf.Level, minSize: conf.MinSize}
+	if opts.level == 0 {
+		opts.level = gzip.DefaultCompression
+	} else if opts.level < gzip.HuffmanOnly || opts.level > gzip.BestCompression {
+		return nil, errCompressLevel(conf.Level)
+	}
+	if opts.minSize <= 0 {
+		opts.minSize = defaultCompressMinSize
+	}
+	opts.excludeTypes = append(opts.excludeTypes, compressedTypes...)
+	for _, t := range strings.Split(conf.ExcludeTypes, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); len(t) > 0 {
+			opts.excludeTypes = append(opts.excludeTypes, t)
+		}
+	}
+	return opts, nil
+}
+
+// compressible check the content type can be compressed
+func (opts *compressOptions) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range compressibleImages {
+		if mediaType == t {
+			return true
+		}
+	}
+	for _, t := range opts.excludeTypes {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return false
+		}
+	}
+	return true
+}
+
+// writer get the compressor of the encoding from the pool
+func (opts *compressOptions) writer(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == "gzip" {
+		if gw, ok := opts.gzipPool.Get().(*gzip.Writer); ok {
+			gw.Reset(w)
+			return gw
+		}
+		gw, _ := gzip.NewWriterLevel(w, opts.level)
+		return gw
+	}
+	if zw, ok := opts.zlibPool.Get().(*zlib.Writer); ok {
+		zw.Reset(w)
+		return zw
+	}
+	zw, _ := zlib.NewWriterLevel(w, opts.level)
+	return zw
+}
+
+func (opts *compressOptions) putWriter(wc io.WriteCloser) {
+	switch w := wc.(type) {
+	case *gzip.Writer:
+		opts.gzipPool.Put(w)
+	case *zlib.Writer:
+		opts.zlibPool.Put(w)
+	}
+}
+
+// initCompression set the response compression by the server config
+func (app *Application) initCompression() error {
+	var conf = app.config.ServerConfig
+	if conf == nil || conf.Compression == nil || !conf.Compression.Enabled {
+		app.compression = nil
+		return nil
+	}
+	opts, err := newCompressOptions(conf.Compression)
+	if err != nil {
+		return err
+	}
+	app.compression = opts
+	return nil
+}
+
+// acceptEncoding get the encoding in the 'Accept-Encoding' header that has the highest quality value.
+// The gzip encoding is preferred to the deflate encoding, and the empty string is returned if neither is acceptable
+func acceptEncoding(header string) string {
+	var gzipQ, deflateQ, anyQ = -1.0, -1.0, -1.0
+	for _, item := range strings.Split(header, ",") {
+		var name, q = strings.TrimSpace(item), 1.0
+		if index := strings.IndexByte(name, ';'); index >= 0 {
+			var param = strings.TrimSpace(name[index+1:])
+			name = strings.TrimSpace(name[:index])
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			value, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil {
+				continue
+			}
+			q = value
+		}
+		switch strings.ToLower(name) {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "deflate":
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return "gzip"
+	case deflateQ > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter the response writer that compresses the response body. The body is buffered until the size of it
+// reaches the minimum size, and the response is not compressed if the body is smaller than the minimum size
+type compressWriter struct {
+	http.ResponseWriter
+	req        *http.Request
+	opts       *compressOptions
+	encoding   string
+	buf        []byte
+	writer     io.WriteCloser
+	statusCode int
+	decided    bool
+	compress   bool
+}
+
+// compressWriter wrap the response writer to compress the response body, nil is returned if the compression is
+// disabled or the request does not accept gzip or deflate
+func (app *Application) compressWriter(w http.ResponseWriter, req *http.Request) *compressWriter {
+	var opts = app.compression
+	if opts == nil {
+		return nil
+	}
+	var encoding = acceptEncoding(req.Header.Get("Accept-Encoding"))
+	if len(encoding) == 0 {
+		return nil
+	}
+	return &compressWriter{ResponseWriter: w, req: req, opts: opts, encoding: encoding}
+}
+
+// WriteHeader record the status code, the header is sent when the compression is decided
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	if statusCode < 200 {
+		// the informational responses are sent directly
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if cw.decided || cw.statusCode > 0 {
+		return
+	}
+	cw.statusCode = statusCode
+	if statusCode == 204 || statusCode == 304 {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) Write(data []byte) (int, error) {
+	if cw.statusCode == 0 {
+		cw.statusCode = 200
+	}
+	if cw.decided {
+		if cw.compress {
+			return cw.writer.Write(data)
+		}
+		return cw.ResponseWriter.Write(data)
+	}
+	cw.buf = append(cw.buf, data...)
+	if len(cw.buf) >= cw.opts.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// decide decide whether the response is compressed and send the header and the buffered body.
+// The response is compressed only if it is allowed by the size, the status code and the headers
+func (cw *compressWriter) decide(allowed bool) error {
+	cw.decided = true
+	var header = cw.Header()
+	if _, ok := header["Content-Type"]; !ok && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	var eligible = len(header.Get("Content-Encoding")) == 0 && len(header.Get("Content-Range")) == 0 &&
+		cw.statusCode != 206 && cw.opts.compressible(header.Get("Content-Type"))
+	if eligible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if allowed && eligible && cw.req.Method != "HEAD" && cw.statusCode != 204 && cw.statusCode != 304 {
+		cw.compress = true
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", cw.encoding)
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, "\"") {
+			header.Set("ETag", strAdd("W/", etag))
+		}
+		cw.writer = cw.opts.writer(cw.encoding, cw.ResponseWriter)
+	}
+	if cw.statusCode > 0 {
+		cw.ResponseWriter.WriteHeader(cw.statusCode)
+	}
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	var buf = cw.buf
+	cw.buf = nil
+	var err error
+	if cw.compress {
+		_, err = cw.writer.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush send the buffered body to the client. The response is compressed if it is allowed, no matter the size of it
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.statusCode == 0 {
+			cw.statusCode = 200
+		}
+		cw.decide(true)
+	}
+	if cw.compress {
+		if f, ok := cw.writer.(interface{ Flush() error }); ok {
+			f.Flush()
+		}
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack hijack the connection if the response writer supports http.Hijacker
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errHijackNotSupported
+}
+
+// Unwrap get the original response writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close send the buffered body and finish the compressed body
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if cw.statusCode == 0 {
+			return nil
+		}
+		cw.decide(false)
+	}
+	if cw.writer == nil {
+		return nil
+	}
+	err := cw.writer.Close()
+	cw.opts.putWriter(cw.writer)
+	cw.writer = nil
+	return err
+}
@@ -0,0 +1,101 @@
+package wemvc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_acceptEncoding(t *testing.T) {
+	var tests = map[string]string{
+		"":                          "",
+		"gzip, deflate, br":         "gzip",
+		"deflate":                   "deflate",
+		"gzip;q=0.5, deflate":       "deflate",
+		"gzip;q=0, *":               "deflate",
+		"*;q=0.1":                   "gzip",
+		"identity, gzip;q=0":        "",
+		"br, x-gzip;q=0.8, deflate": "deflate",
+	}
+	for header, expected := range tests {
+		if encoding := acceptEncoding(header); encoding != expected {
+			t.Error("failed", header, encoding)
+		}
+	}
+}
+
+func Test_Application_compression(t *testing.T) {
+	root, err := ioutil.TempDir("", "wemvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	var page = strings.Repeat("<p>compressed content</p>", 20)
+	os.MkdirAll(root+"/static", 0755)
+	ioutil.WriteFile(root+"/static/page.html", []byte(page), 0644)
+	ioutil.WriteFile(root+"/static/small.css", []byte("body{}"), 0644)
+	ioutil.WriteFile(root+"/static/image.png", []byte(page), 0644)
+	ioutil.WriteFile(root+"/config.xml", []byte(`<configuration>
+	<server><compression enabled="true" minSize="64"/></server>
+</configuration>`), 0644)
+	app := New(root)
+	app.StaticDir("/static")
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	var tests = []struct {
+		url      string
+		accept   string
+		encoding string
+		vary     bool
+	}{
+		{"/static/page.html", "gzip, deflate", "gzip", true},
+		{"/static/page.html", "deflate", "deflate", true},
+		{"/static/page.html", "", "", false},
+		{"/static/small.css", "gzip", "", true},
+		{"/static/image.png", "gzip", "", false},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", test.url, nil)
+		if len(test.accept) > 0 {
+			req.Header.Set("Accept-Encoding", test.accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != 200 || w.Header().Get("Content-Encoding") != test.encoding ||
+			(w.Header().Get("Vary") == "Accept-Encoding") != test.vary {
+			t.Error("test", i, "failed", w.Code, w.Header())
+			continue
+		}
+		var body = w.Body.Bytes()
+		switch test.encoding {
+		case "gzip":
+			r, _ := gzip.NewReader(bytes.NewReader(body))
+			body, _ = ioutil.ReadAll(r)
+		case "deflate":
+			r, _ := zlib.NewReader(bytes.NewReader(body))
+			body, _ = ioutil.ReadAll(r)
+		}
+		if test.url == "/static/page.html" && string(body) != page {
+			t.Error("test", i, "failed", len(body))
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/static/page.html", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=0-9")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || len(w.Header().Get("Content-Encoding")) > 0 || w.Body.Len() != 10 {
+		t.Error("test range failed", w.Code, w.Header())
+	}
+}
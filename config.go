@@ -36,6 +36,7 @@ type config struct {
 		} `xml:"add"`
 	} `xml:"settings"`
 	SessionConfig *SessionConfig `xml:"session"`
+	ServerConfig  *ServerConfig  `xml:"server"`
 	settingMap    map[string]string
 	connMap       map[string]*connSetting
 	defaultUrls   []string
@@ -88,6 +89,9 @@ func (conf *config) loadFile(file string) error {
 	if conf.SessionConfig.MaxLifetime == 0 {
 		conf.SessionConfig.MaxLifetime = 3600
 	}
+	if conf.ServerConfig == nil {
+		conf.ServerConfig = &ServerConfig{}
+	}
 	return nil
 }
 
@@ -26,6 +26,7 @@ type CtxController struct {
 
 	controllerType   reflect.Type
 	actionMethod     reflect.Value
+	actionParams     []*actionParam
 }
 
 // Context the request context
@@ -33,8 +34,13 @@ type Context struct {
 	req      *http.Request
 	w        http.ResponseWriter
 	ctxItems *CtxItems
-	app      *server
+	app      *Application
 	ended    bool
+	// staticURL the url path of the static request in the letter case of the static path setting
+	staticURL string
+	session   SessionStore
+	// sessionUnlock release the request lock of the session
+	sessionUnlock func()
 
 	Route  *CtxRoute
 	Ctrl   *CtxController
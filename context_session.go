@@ -0,0 +1,68 @@
+package wemvc
+
+// Session start the session of the request and get the session store. The session is locked until the end of the
+// request if the 'requestLock' attribute of the session config is true
+func (ctx *Context) Session() SessionStore {
+	if ctx.session == nil {
+		var mgr = ctx.app.globalSession
+		ctx.sessionUnlock = mgr.lockRequest(ctx.Request())
+		session, err := mgr.SessionStart(ctx.Response(), ctx.Request())
+		if err != nil {
+			ctx.unlockSession()
+			panic(err)
+		}
+		ctx.session = session
+	}
+	return ctx.session
+}
+
+// DestroySession destroy the session of the request and remove the session cookie.
+// The destroyed session is not saved at the end of the request
+func (ctx *Context) DestroySession() {
+	ctx.app.globalSession.SessionDestroy(ctx.Response(), ctx.Request())
+	ctx.session = nil
+	ctx.unlockSession()
+}
+
+// RegenerateSession regenerate the session id of the request and get the new session store.
+// The values of the current session store, including the changes that are not saved, are kept in the new one
+func (ctx *Context) RegenerateSession() SessionStore {
+	var current = ctx.session
+	var session = ctx.app.globalSession.SessionRegenerateID(ctx.Response(), ctx.Request())
+	if session == nil {
+		return current
+	}
+	if current != nil && current != session {
+		session.Flush()
+		for _, key := range current.Keys() {
+			session.Set(key, current.Get(key))
+		}
+	}
+	ctx.session = session
+	// the old session id does not exist anymore
+	ctx.unlockSession()
+	return session
+}
+
+// releaseSession save the session store if it is changed and release the request lock of the session. It is called
+// after the action is executed and before the result is written, so the session cookie can be set.
+// It panics if the session store reports the error of saving the values, so the lost changes are not ignored
+func (ctx *Context) releaseSession() {
+	if ctx.session != nil {
+		if ds, ok := ctx.session.(dirtySessionStore); !ok || ds.Dirty() {
+			ctx.session.SessionRelease(ctx.w)
+			if es, ok := ctx.session.(errSessionStore); ok && es.Err() != nil {
+				ctx.unlockSession()
+				panic(es.Err())
+			}
+		}
+	}
+	ctx.unlockSession()
+}
+
+func (ctx *Context) unlockSession() {
+	if ctx.sessionUnlock != nil {
+		ctx.sessionUnlock()
+		ctx.sessionUnlock = nil
+	}
+}
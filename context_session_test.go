@@ -0,0 +1,185 @@
+package wemvc
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+type testSessionCtrl struct {
+	Controller
+}
+
+func (ctrl testSessionCtrl) GetIncr() interface{} {
+	var count = ctrl.Session().GetInt("count") + 1
+	ctrl.Session().Set("count", count)
+	return ctrl.PlainText(strconv.Itoa(count))
+}
+
+func (ctrl testSessionCtrl) GetRead() interface{} {
+	return ctrl.PlainText(strconv.Itoa(ctrl.Session().GetInt("count")))
+}
+
+func (ctrl testSessionCtrl) GetLogin() interface{} {
+	ctrl.Session().Set("user", "steve")
+	var session = ctrl.RegenerateSession()
+	return ctrl.PlainText(session.GetString("user") + " " + strconv.Itoa(session.GetInt("count")))
+}
+
+func (ctrl testSessionCtrl) GetLogout() interface{} {
+	ctrl.Session().Set("count", 100)
+	ctrl.DestroySession()
+	return ctrl.PlainText("bye")
+}
+
+func (ctrl testSessionCtrl) GetTags() interface{} {
+	tags, ok := ctrl.Session().Get("tags").([]string)
+	if !ok {
+		ctrl.Session().Set("tags", []string{"a"})
+		return ctrl.PlainText("a")
+	}
+	// the slice is changed in place, so the session should be marked as dirty
+	tags[0] += "a"
+	ctrl.Session().MarkDirty()
+	return ctrl.PlainText(tags[0])
+}
+
+func newSessionTestApp(t *testing.T, session string) (*Application, http.Handler, func()) {
+	root, err := ioutil.TempDir("", "wemvc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(root+"/config.xml", []byte(`<configuration>`+session+`</configuration>`), 0644)
+	app := New(root)
+	app.Route("/<action>", testSessionCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		os.RemoveAll(root)
+		t.Fatal(err)
+	}
+	return app, h, func() {
+		app.Shutdown(context.Background())
+		os.RemoveAll(root)
+	}
+}
+
+func serveSession(h http.Handler, url string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func Test_Context_releaseSession(t *testing.T) {
+	_, h, closeApp := newSessionTestApp(t, `<session manager="cookie" cookieName="sid" providerConfig="`+testCookieKey1+`"/>`)
+	defer closeApp()
+
+	// the unchanged new session does not set the cookie
+	w := serveSession(h, "/read", nil)
+	if w.Body.String() != "0" || len(w.Result().Cookies()) != 0 {
+		t.Error("test 1 failed", w.Body.String(), w.Header())
+	}
+	w = serveSession(h, "/incr", nil)
+	if w.Body.String() != "1" || len(w.Result().Cookies()) != 1 {
+		t.Fatal("test 2 failed", w.Body.String(), w.Header())
+	}
+	var cookie = w.Result().Cookies()[0]
+	w = serveSession(h, "/incr", cookie)
+	if w.Body.String() != "2" || len(w.Result().Cookies()) != 1 {
+		t.Fatal("test 3 failed", w.Body.String())
+	}
+	cookie = w.Result().Cookies()[0]
+
+	// the unchanged session is not written again
+	w = serveSession(h, "/read", cookie)
+	if w.Body.String() != "2" || len(w.Result().Cookies()) != 0 {
+		t.Error("test 4 failed", w.Body.String(), w.Header())
+	}
+
+	// the regenerated session keeps the values that are not saved
+	w = serveSession(h, "/login", cookie)
+	if w.Body.String() != "steve 2" || len(w.Result().Cookies()) != 1 {
+		t.Fatal("test 5 failed", w.Body.String(), w.Header())
+	}
+	cookie = w.Result().Cookies()[0]
+	if w = serveSession(h, "/read", cookie); w.Body.String() != "2" {
+		t.Error("test 6 failed", w.Body.String())
+	}
+
+	// the value that is changed in place is saved by MarkDirty
+	w = serveSession(h, "/tags", cookie)
+	if w.Body.String() != "a" || len(w.Result().Cookies()) != 1 {
+		t.Fatal("test 7 failed", w.Body.String())
+	}
+	w = serveSession(h, "/tags", w.Result().Cookies()[0])
+	if w.Body.String() != "aa" || len(w.Result().Cookies()) != 1 {
+		t.Fatal("test 8 failed", w.Body.String())
+	}
+	if w = serveSession(h, "/tags", w.Result().Cookies()[0]); w.Body.String() != "aaa" {
+		t.Error("test 9 failed", w.Body.String())
+	}
+
+	// the destroyed session is not written again
+	w = serveSession(h, "/logout", cookie)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge != -1 {
+		t.Error("test 10 failed", w.Header())
+	}
+}
+
+func Test_SessionManager_lockRequest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	app, h, closeApp := newSessionTestApp(t, `<session manager="file" cookieName="sid" requestLock="true" providerConfig="`+dir+`"/>`)
+	defer closeApp()
+	w := serveSession(h, "/incr", nil)
+	var cookies = w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatal("test 1 failed", w.Header())
+	}
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveSession(h, "/incr", cookies[0])
+		}()
+	}
+	wg.Wait()
+	if w = serveSession(h, "/read", cookies[0]); w.Body.String() != strconv.Itoa(n+1) {
+		t.Error("test 2 failed", w.Body.String())
+	}
+	if len(app.globalSession.reqLocks) != 0 {
+		t.Error("test 3 failed")
+	}
+}
+
+func Test_CookieSessionStore_secure(t *testing.T) {
+	_, h, closeApp := newSessionTestApp(t, `<session manager="cookie" cookieName="sid" secure="true" providerConfig="`+testCookieKey1+`"/>`)
+	defer closeApp()
+
+	// the secure cookie is only set for the https requests
+	w := serveSession(h, "/incr", nil)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Secure {
+		t.Fatal("test 1 failed", w.Header())
+	}
+	w = serveSession(h, "https://example.com/incr", w.Result().Cookies()[0])
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure || w.Body.String() != "2" {
+		t.Fatal("test 2 failed", w.Body.String(), w.Header())
+	}
+	w = serveSession(h, "https://example.com/login", w.Result().Cookies()[0])
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure {
+		t.Error("test 3 failed", w.Header())
+	}
+}
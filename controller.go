@@ -3,7 +3,9 @@ package wemvc
 import (
 	"encoding/json"
 	"encoding/xml"
+	"io"
 	"net/http"
+	"time"
 )
 
 // Initializable indicate the controller can be initialized
@@ -13,9 +15,9 @@ type Initializable interface {
 
 // Controller the controller base struct
 type Controller struct {
-	ViewData map[string]interface{}
-	session  SessionStore
-	ctx      *Context
+	ViewData   map[string]interface{}
+	ModelState *ModelState
+	ctx        *Context
 }
 
 // Request get the http request
@@ -58,26 +60,51 @@ func (ctrl *Controller) Cache() *CacheManager {
 	return ctrl.ctx.app.cacheManager
 }
 
-//MapPath Returns the physical file path that corresponds to the specified virtual path.
+// MapPath Returns the physical file path that corresponds to the specified virtual path.
 func (ctrl *Controller) MapPath(virtualPath string) string {
 	return ctrl.ctx.app.mapPath(virtualPath)
 }
 
-// Session start the session and get the session store
+// Session start the session and get the session store.
+// The session store is released automatically after the action is executed
 func (ctrl *Controller) Session() SessionStore {
-	if ctrl.session == nil {
-		session, err := ctrl.ctx.app.globalSession.SessionStart(ctrl.Response(), ctrl.Request())
-		if err != nil {
-			panic(err)
-		}
-		ctrl.session = session
+	return ctrl.ctx.Session()
+}
+
+// DestroySession destroy the session and remove the session cookie
+func (ctrl *Controller) DestroySession() {
+	ctrl.ctx.DestroySession()
+}
+
+// RegenerateSession regenerate the session id and get the new session store, the values of the session are kept
+func (ctrl *Controller) RegenerateSession() SessionStore {
+	return ctrl.ctx.RegenerateSession()
+}
+
+// Bind bind the request to the model by the content type of the request. The form, multipart form, JSON and XML
+// request bodies are supported. The size of the request body is limited by the 'MaxBodySize' setting. The child
+// elements of the XML root element are bound by the field names like the form values, the 'xml' tags are not used.
+// The *BindError is returned if the request can not be bound to the model, and the field errors are added to the ModelState
+func (ctrl *Controller) Bind(model interface{}) error {
+	err := ctrl.ctx.bind(model)
+	if bindErr, ok := err.(*BindError); ok {
+		ctrl.ModelState.AddErrors(bindErr.Fields)
 	}
-	return ctrl.session
+	return err
+}
+
+// Validate validate the model by the 'validate' tags, the field errors are added to the ModelState.
+// It returns true if the model is valid
+func (ctrl *Controller) Validate(model interface{}) bool {
+	errs := Validate(model)
+	ctrl.ModelState.AddErrors(errs)
+	return len(errs) == 0
 }
 
 // OnInit this method is called at first while executing the controller
 func (ctrl *Controller) OnInit(ctx *Context) {
 	ctrl.ViewData = make(map[string]interface{})
+	ctrl.ModelState = &ModelState{}
 	ctrl.ctx = ctx
 }
 
@@ -87,6 +114,7 @@ func (ctrl *Controller) initViewData() {
 	ctrl.ViewData["Request"] = ctrl.Request()
 	ctrl.ViewData["Session"] = ctrl.Session()
 	ctrl.ViewData["Cache"] = ctrl.Cache()
+	ctrl.ViewData["ModelState"] = ctrl.ModelState
 }
 
 // ViewFile execute a view file and return the HTML
@@ -103,6 +131,7 @@ func (ctrl *Controller) ViewFile(viewPath string) Result {
 		panic(err)
 	}
 	var resp = NewResult()
+	resp.AutoETag = ctrl.ctx.app.autoETag
 	resp.Write(res)
 	return resp
 }
@@ -115,6 +144,7 @@ func (ctrl *Controller) View() Result {
 // Content return the content as text
 func (ctrl *Controller) Content(str string, cntType string) Result {
 	var resp = NewResult()
+	resp.AutoETag = ctrl.ctx.app.autoETag
 	if len(cntType) < 1 {
 		resp.ContentType = "text/plain"
 	} else {
@@ -159,6 +189,29 @@ func (ctrl *Controller) XML(data interface{}) Result {
 	return ctrl.Content(byte2Str(bytes), "text/xml")
 }
 
+// Negotiate return the data formatted by the formatter that is accepted by the request, like JSON, XML or plain text.
+// The 406 Not Acceptable response is returned if no formatter is acceptable
+func (ctrl *Controller) Negotiate(data interface{}) Result {
+	return &NegotiateResult{Data: data, StatusCode: 200, app: ctrl.ctx.app}
+}
+
+// Stream return the content of the reader without buffering it, the reader is closed if it is an io.Closer
+func (ctrl *Controller) Stream(reader io.Reader, cntType string) Result {
+	return &StreamResult{Reader: reader, ContentType: cntType}
+}
+
+// StreamFunc return the content written by the function without buffering it.
+// Call the Flush method of the writer to send the written data to the client
+func (ctrl *Controller) StreamFunc(cntType string, fn func(w *StreamWriter) error) Result {
+	return &StreamResult{Writer: fn, ContentType: cntType}
+}
+
+// SSE send the events in the channel as the server-sent events until the channel is closed.
+// The heartbeat is sent if no event is sent in the heartbeat duration, and it is disabled if the duration is 0
+func (ctrl *Controller) SSE(events <-chan *SSEvent, heartbeat time.Duration) Result {
+	return &SSEResult{Events: events, Heartbeat: heartbeat}
+}
+
 // File serve the file as action result
 func (ctrl *Controller) File(path string, cntType string) Result {
 	var resp = &FileResult{
@@ -168,6 +221,22 @@ func (ctrl *Controller) File(path string, cntType string) Result {
 	return resp
 }
 
+// Download serve the file as attachment, the file name of the path is used if the fileName is empty
+func (ctrl *Controller) Download(path string, fileName string) Result {
+	return &DownloadResult{FilePath: path, FileName: fileName, app: ctrl.ctx.app}
+}
+
+// DownloadData serve the data as attachment with the file name
+func (ctrl *Controller) DownloadData(data []byte, fileName string, cntType string) Result {
+	return &DownloadResult{Data: data, FileName: fileName, ContentType: cntType, app: ctrl.ctx.app}
+}
+
+// DownloadReader serve the content of the reader as attachment with the file name, the reader is closed if it is an
+// io.Closer. The modTime is used by the conditional requests, and it is ignored if it is zero
+func (ctrl *Controller) DownloadReader(reader io.ReadSeeker, fileName string, cntType string, modTime time.Time) Result {
+	return &DownloadResult{Reader: reader, FileName: fileName, ContentType: cntType, ModTime: modTime, app: ctrl.ctx.app}
+}
+
 func (ctrl *Controller) redirect(url string, statusCode int) *RedirectResult {
 	var resp = &RedirectResult{
 		StatusCode:  statusCode,
@@ -186,6 +255,20 @@ func (ctrl *Controller) RedirectPermanent(url string) Result {
 	return ctrl.redirect(url, 301)
 }
 
+// URLFor generate the URL of the named route with the route params
+func (ctrl *Controller) URLFor(name string, params map[string]string) (string, error) {
+	return ctrl.ctx.app.urlFor(name, params)
+}
+
+// RedirectToRoute Redirects a request to the URL of the named route.
+func (ctrl *Controller) RedirectToRoute(name string, params map[string]string) Result {
+	url, err := ctrl.URLFor(name, params)
+	if err != nil {
+		panic(err)
+	}
+	return ctrl.redirect(url, 302)
+}
+
 // NotFound return a 404 page as action result
 func (ctrl *Controller) NotFound() Result {
 	return ctrl.ctx.app.handleErrorReq(ctrl.Request(), 404)
@@ -10,7 +10,9 @@ type controllerInfo struct {
 	CtrlName      string
 	CtrlType      reflect.Type
 	Actions       map[string]string
+	ActionParams  map[string][]*actionParam
 	DefaultAction string
+	Methods       []string
 }
 
 func (ctrlInfo *controllerInfo) findActionName(actionName, method string, friendly bool) string {
@@ -3,8 +3,13 @@ package wemvc
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"html/template"
+	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type errEndRequest struct{}
@@ -33,8 +38,135 @@ var errInvalidRoute = errors.New("Invalid route parameter. If you want to use ro
 
 var errTooManyParam = errors.New("Too many route params. The maximum number of the route param is 255")
 
+var errCompressLevel = func(level int) error {
+	return errors.New(strAdd("Invalid compression level ", strconv.Itoa(level), ". The level should be between -2 and 9"))
+}
+
+var errHijackNotSupported = errors.New("The response writer does not support hijacking")
+
+var errFormatterNil = errors.New("The formatter is nil")
+
+var errInvalidMediaType = func(contentType string) error {
+	return errors.New(strAdd("Invalid media type '", contentType, "'"))
+}
+
+var errSessionCookieKey = errors.New("Invalid session cookie key. The keys should be 16, 24 or 32 bytes in hex or base64, and separated by ','")
+
+var errSessionCookieTooLarge = errors.New("The session cookie is too large")
+
+var errInvalidSessionID = errors.New("The session id is invalid")
+
+var errSessionSQLConfig = func(config string) error {
+	return errors.New(strAdd("Invalid sql session config '", config, "'. The config should be the connection name and the optional table name like 'sessionDb,sessions'"))
+}
+
+var errSessionSQLConn = func(connName string) error {
+	return errors.New(strAdd("The connection string '", connName, "' of the sql session is not found"))
+}
+
 var errSessionProvNil = errors.New("The session provider is nil")
 
+var errSessionCodecNil = errors.New("The session codec is nil")
+
+var errSessionCodec = func(name string) error {
+	return errors.New(strAdd("Unknown session codec '", name, "'"))
+}
+
+var errSessionKeyType = func(key interface{}) error {
+	return fmt.Errorf("The session key %#v is not a string", key)
+}
+
+var errSessionKeyNotFound = func(key string) error {
+	return errors.New(strAdd("The session value '", key, "' does not exist"))
+}
+
+var errSessionObjectDst = errors.New("The destination of the session object should be a non-nil pointer")
+
+var errInvalidMethod = func(method string) error {
+	return errors.New(strAdd("Invalid http method \"", method, "\""))
+}
+
+// errMethodNotAllowed the error returned by the route tree when the path matches but the http method does not
+type errMethodNotAllowed struct {
+	allowed []string
+}
+
+func (err *errMethodNotAllowed) Error() string {
+	return strAdd("The http method is not allowed. Allow: ", strings.Join(err.allowed, ", "))
+}
+
+func newErrMethodNotAllowed(allowed map[string]bool) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	var methods = make([]string, 0, len(allowed))
+	for m := range allowed {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return &errMethodNotAllowed{allowed: methods}
+}
+
+var errInvalidURLPath = errors.New("Invalid URL path. The URL path cannot contains '..'")
+
+var errInvalidURLPolicy = func(policy string) error {
+	return errors.New(strAdd("Invalid URL policy \"", policy, "\". The policy should be 'lenient', 'strict' or 'redirect'"))
+}
+
+var errRouteNameEmpty = errors.New("The route name cannot be empty")
+
+var errRouteNameTwice = func(name string) error {
+	return errors.New(strAdd("Duplicate route name \"", name, "\""))
+}
+
+var errRouteNotFound = func(name string) error {
+	return errors.New(strAdd("Cannot find the route named \"", name, "\""))
+}
+
+var errRouteParamMissing = func(routePath, paramName string) error {
+	return errors.New(strAdd("The route param \"", paramName, "\" is required by the route \"", routePath, "\""))
+}
+
+var errRouteParamInvalid = func(routePath, paramName, value string) error {
+	return errors.New(strAdd("Invalid value \"", value, "\" of the route param \"", paramName, "\" in the route \"", routePath, "\""))
+}
+
+var errValidateTag = func(t reflect.Type, fieldName, rule string) error {
+	return errors.New(strAdd("Invalid validation rule \"", rule, "\" of the field ", t.String(), ".", fieldName))
+}
+
+var errBindModel = errors.New("The model to bind should be a pointer to struct")
+
+var errBodyTooLarge = errors.New("The request body is too large")
+
+var errValueOverflow = func(value string, t reflect.Type) error {
+	return errors.New(strAdd("The value ", value, " overflows ", t.String()))
+}
+
+var errValueType = func(valueType, fieldType reflect.Type) error {
+	return errors.New(strAdd("Cannot assign the value of type ", valueType.String(), " to ", fieldType.String()))
+}
+
+var errSliceIndex = func(index, max int) error {
+	return errors.New(strAdd("The index ", strconv.Itoa(index), " is out of range, the maximum index is ", strconv.Itoa(max)))
+}
+
+var errTimeFormat = func(value string) error {
+	return errors.New(strAdd("The value \"", value, "\" is not a valid time"))
+}
+
+var errActionParam = func(paramName string, err error) error {
+	return errors.New(strAdd("Invalid value of the action parameter \"", paramName, "\": ", err.Error()))
+}
+
+var errActionParamNames = func(typeName, methodName string) error {
+	return errors.New(strAdd("The parameter names of the action \"", typeName, ".", methodName, "\" does not match the parameters"))
+}
+
+var errActionParamsUndeclared = func(typeName, methodName string) error {
+	return errors.New(strAdd("The parameter names of the action \"", typeName, ".", methodName, "\" are not declared by ActionParams"))
+}
+
 var errNotFoundTpl = func(file string) error {
 	return errors.New(strAdd("can't find template file \"", file, "\""))
 }
@@ -22,6 +22,7 @@ type FileWatcher struct {
 	handlers       []WatcherHandler
 	errorProcessor WatcherErrorHandler
 	started        bool
+	done           chan struct{}
 }
 
 // AddWatch add path to watch
@@ -57,13 +58,21 @@ func (fw *FileWatcher) Start() {
 	go func() {
 		for {
 			select {
-			case ev := <-fw.watcher.Events:
+			case <-fw.done:
+				return
+			case ev, ok := <-fw.watcher.Events:
+				if !ok {
+					return
+				}
 				for _, detector := range fw.handlers {
 					if detector.CanHandle(path.Clean(ev.Name)) {
 						detector.Handle(&ev)
 					}
 				}
-			case err := <-fw.watcher.Errors:
+			case err, ok := <-fw.watcher.Errors:
+				if !ok {
+					return
+				}
 				if fw.errorProcessor != nil {
 					fw.errorProcessor(err)
 				}
@@ -72,6 +81,18 @@ func (fw *FileWatcher) Start() {
 	}()
 }
 
+// Close stop the file watcher goroutine and release the fsnotify watcher
+func (fw *FileWatcher) Close() error {
+	select {
+	case <-fw.done:
+		return nil
+	default:
+	}
+	close(fw.done)
+	fw.started = false
+	return fw.watcher.Close()
+}
+
 // NewWatcher create the new watcher
 func NewWatcher() (*FileWatcher, error) {
 	tmpWatcher, err := fsnotify.NewWatcher()
@@ -80,6 +101,7 @@ func NewWatcher() (*FileWatcher, error) {
 	}
 	w := &FileWatcher{
 		watcher: tmpWatcher,
+		done:    make(chan struct{}),
 	}
 	return w, nil
 }
@@ -0,0 +1,48 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package wemvc
+
+import (
+	"os"
+	"sync"
+)
+
+type fileLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// fileLocks the in-process locks of the files, the files are not locked across processes on the platforms without flock
+var fileLocks = struct {
+	sync.Mutex
+	locks map[string]*fileLock
+}{locks: make(map[string]*fileLock)}
+
+// lockFile lock the file exclusively in the process, it blocks until the lock is acquired
+func lockFile(f *os.File) error {
+	fileLocks.Lock()
+	l, ok := fileLocks.locks[f.Name()]
+	if !ok {
+		l = &fileLock{}
+		fileLocks.locks[f.Name()] = l
+	}
+	l.refs++
+	fileLocks.Unlock()
+	l.mu.Lock()
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	fileLocks.Lock()
+	defer fileLocks.Unlock()
+	l, ok := fileLocks.locks[f.Name()]
+	if !ok {
+		return nil
+	}
+	l.mu.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(fileLocks.locks, f.Name())
+	}
+	return nil
+}
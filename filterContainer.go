@@ -9,7 +9,7 @@ type filterContainer struct {
 	filters map[string][]CtxFilter
 }
 
-func (fc *filterContainer) execFilters(urlPath string, ctx *Context) {
+func (fc *filterContainer) execFilters(urlPath string, matchCase bool, ctx *Context) {
 	if len(fc.filters) < 1 {
 		return
 	}
@@ -19,7 +19,7 @@ func (fc *filterContainer) execFilters(urlPath string, ctx *Context) {
 	var tmpFilters = fc.filters
 	var keys []string
 	for key := range tmpFilters {
-		if strings.HasPrefix(urlPath, key) {
+		if strings.HasPrefix(urlPath, key) || (!matchCase && hasPrefixFold(urlPath, key)) {
 			keys = append(keys, key)
 		}
 	}
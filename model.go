@@ -1,238 +1,287 @@
 package wemvc
 
 import (
-	"net/url"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
-// Model2Map convert model to map[string]interface{}
+// Model2Map convert model or the pointer to model to map[string]interface{}. The keys are the field names set by the
+// 'field' tag, the 'json' tag or the field name, the fields tagged with '-' and the empty fields tagged with 'omitempty'
+// are skipped. The fields of the embedded structs are flattened, and the nested structs are converted to nested maps,
+// so the map can be parsed back to the model by ModelParse
 func Model2Map(m interface{}) map[string]interface{} {
 	if m == nil {
 		return nil
 	}
 	mValue := reflect.ValueOf(m)
-	mType := reflect.TypeOf(m)
-	fieldNum := mType.NumField()
-	data := make(map[string]interface{}, fieldNum)
-	for i := 0; i < fieldNum; i++ {
+	for mValue.Kind() == reflect.Ptr {
+		if mValue.IsNil() {
+			return nil
+		}
+		mValue = mValue.Elem()
+	}
+	if mValue.Kind() != reflect.Struct {
+		return nil
+	}
+	data := make(map[string]interface{}, mValue.NumField())
+	model2Map(mValue, data)
+	return data
+}
+
+// model2Map set the fields of the struct to the map. The fields of the embedded structs are set after the other fields,
+// so the fields of the outer struct take precedence
+func model2Map(mValue reflect.Value, data map[string]interface{}) {
+	mType := mValue.Type()
+	var embedded []reflect.Value
+	for i := 0; i < mType.NumField(); i++ {
 		field := mType.Field(i)
-		fieldName, ok := field.Tag.Lookup("field")
-		if !ok || len(fieldName) == 0 {
-			fieldName, ok = field.Tag.Lookup("json")
-			if !ok || len(fieldName) == 0 {
-				fieldName = field.Name
+		name, omitEmpty, skip := modelFieldTag(field)
+		if skip {
+			continue
+		}
+		fieldValue := mValue.Field(i)
+		if isEmbeddedModel(field, name) {
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
 			}
+			embedded = append(embedded, fieldValue)
+			continue
+		}
+		if len(field.PkgPath) > 0 || (omitEmpty && isZeroValue(fieldValue)) {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		if _, ok := data[name]; !ok {
+			data[name] = mapValue(fieldValue)
 		}
-		fieldValue := mValue.Field(i).Interface()
-		data[fieldName] = fieldValue
 	}
-	return data
+	for _, e := range embedded {
+		model2Map(e, data)
+	}
 }
 
-// ModelParse convert the values(url.Values, map[string]interface{}) to model
-func ModelParse(m interface{}, values interface{}) {
-	if values == nil || m == nil {
-		return
+// mapValue get the value of the field that is set to the map, the struct values are converted to maps
+func mapValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
 	}
-	mValue := reflect.ValueOf(m)
-	mType := mValue.Elem().Type()
-	mName := mType.Name()
-	fieldNum := mType.NumField()
-	for i := 0; i < fieldNum; i++ {
-		typeField := mType.Field(i)
-		fieldName, ok := typeField.Tag.Lookup("field")
-		if !ok || len(fieldName) == 0 {
-			fieldName, ok = typeField.Tag.Lookup("json")
-			if !ok || len(fieldName) == 0 {
-				fieldName = typeField.Name
-			}
+	if isTextType(v.Type()) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		data := make(map[string]interface{}, v.NumField())
+		model2Map(v, data)
+		return data
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() || !hasModelElem(v.Type().Elem()) {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = mapValue(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String || !hasModelElem(v.Type().Elem()) {
+			return v.Interface()
 		}
-		value := getValue(fieldName, values);
-		if value == nil {
-			value = getValue(strAdd(mName, ".", fieldName), values)
+		data := make(map[string]interface{}, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			data[iter.Key().String()] = mapValue(iter.Value())
 		}
-		if value == nil {
+		return data
+	}
+	return v.Interface()
+}
+
+// hasModelElem check the element of the slice or map is a struct that is converted to map
+func hasModelElem(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isTextType(t)
+}
+
+// isEmbeddedModel check the field is an embedded struct without the name tag, whose fields are flattened
+func isEmbeddedModel(field reflect.StructField, tagName string) bool {
+	if !field.Anonymous || len(tagName) > 0 {
+		return false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isTextType(t)
+}
+
+// ModelParse convert the values(url.Values, map[string]interface{}, map[string]string, ...) to model.
+// The nested struct fields are set by the dotted keys like 'Address.City', the slice fields are set by the repeated keys
+// or the indexed keys like 'Items[0].Name', and the map fields are set by the keys like 'Attrs[color]' or 'Attrs.color'.
+// The time.Time fields are parsed by the 'layout' tag of the field or the layouts set by SetTimeLayouts, and the fields
+// that implement encoding.TextUnmarshaler are set by UnmarshalText. The conversion errors are returned as *BindError
+func ModelParse(m interface{}, values interface{}) error {
+	return modelParse(m, values)
+}
+
+// modelFieldName get the name of the model field by the 'field' tag, the 'json' tag or the field name.
+// The empty string is returned if the field is ignored by the tag '-'
+func modelFieldName(field reflect.StructField) string {
+	name, _, skip := modelFieldTag(field)
+	if skip {
+		return ""
+	}
+	if len(name) == 0 {
+		return field.Name
+	}
+	return name
+}
+
+// modelFieldTag get the name and the 'omitempty' option of the model field by the 'field' tag or the 'json' tag.
+// The name is empty if it is not set by the tags, and skip is true if the field is ignored by the tag '-'
+func modelFieldTag(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	for _, tag := range []string{"field", "json"} {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
 			continue
 		}
-		setFieldValue(typeField.Type.Kind(), mValue.Elem().Field(i), value)
+		var tagName, opts = value, ""
+		var hasOpts = false
+		if index := strings.Index(value, ","); index >= 0 {
+			tagName, opts, hasOpts = value[:index], value[index+1:], true
+		}
+		if tagName == "-" && !hasOpts && len(name) == 0 {
+			return "", false, true
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+		if len(name) == 0 {
+			name = tagName
+		}
 	}
+	return name, omitEmpty, false
 }
 
-func getValue(key string, collection interface{}) interface{} {
-	switch collection.(type) {
-	case url.Values:
-		return collection.(url.Values).Get(key)
-	case *url.Values:
-		return collection.(*url.Values).Get(key)
-	case map[string]interface{}:
-		return collection.(map[string]interface{})[key]
-	case map[string]bool:
-		return collection.(map[string]bool)[key]
-	case map[string]string:
-		return collection.(map[string]string)[key]
-	case map[string]int:
-		return collection.(map[string]int)[key]
-	case map[string]int8:
-		return collection.(map[string]int8)[key]
-	case map[string]int16:
-		return collection.(map[string]int16)[key]
-	case map[string]int32:
-		return collection.(map[string]int32)[key]
-	case map[string]int64:
-		return collection.(map[string]int64)[key]
-	case map[string]uint:
-		return collection.(map[string]uint)[key]
-	case map[string]uint8:
-		return collection.(map[string]uint8)[key]
-	case map[string]uint16:
-		return collection.(map[string]uint16)[key]
-	case map[string]uint32:
-		return collection.(map[string]uint32)[key]
-	case map[string]uint64:
-		return collection.(map[string]uint64)[key]
-	case map[string]float32:
-		return collection.(map[string]float32)[key]
-	case map[string]float64:
-		return collection.(map[string]float64)[key]
+// modelParse convert the values to model, the conversion errors are returned as *BindError
+func modelParse(m interface{}, values interface{}) error {
+	if m == nil {
+		return errBindModel
 	}
-	return nil
+	mValue := reflect.ValueOf(m)
+	if mValue.Kind() != reflect.Ptr || mValue.IsNil() || mValue.Elem().Kind() != reflect.Struct {
+		return errBindModel
+	}
+	if values == nil {
+		return nil
+	}
+	var p = &modelParser{values: newModelValues(values)}
+	if len(p.values) == 0 {
+		return nil
+	}
+	p.parseStruct(mValue.Elem(), "", mValue.Elem().Type().Name())
+	return newFieldErrors(p.fields)
 }
 
-func setFieldInt64(valueField reflect.Value, value interface{}) {
-	if valueStr,ok := value.(string); ok && len(valueStr) > 0 {
-		v, _ := strconv.ParseInt(valueStr, 0, 64)
+func setFieldInt64(valueField reflect.Value, value interface{}) error {
+	if valueStr, ok := value.(string); ok && len(valueStr) > 0 {
+		v, err := strconv.ParseInt(valueStr, 10, 64)
+		if err != nil {
+			return err
+		}
+		if valueField.OverflowInt(v) {
+			return errValueOverflow(valueStr, valueField.Type())
+		}
 		valueField.SetInt(v)
 	}
+	return nil
 }
 
-func setFieldUint64(valueField reflect.Value, value interface{}) {
+func setFieldUint64(valueField reflect.Value, value interface{}) error {
 	if valueStr, ok := value.(string); ok && len(valueStr) > 0 {
-		v, _ := strconv.ParseUint(valueStr, 0, 64)
+		v, err := strconv.ParseUint(valueStr, 10, 64)
+		if err != nil {
+			return err
+		}
+		if valueField.OverflowUint(v) {
+			return errValueOverflow(valueStr, valueField.Type())
+		}
 		valueField.SetUint(v)
 	}
+	return nil
 }
 
-func setFieldFloat64(valueField reflect.Value, value interface{}) {
+func setFieldFloat64(valueField reflect.Value, value interface{}) error {
 	if valueStr, ok := value.(string); ok && len(valueStr) > 0 {
-		v, _ := strconv.ParseFloat(valueStr, 64)
+		v, err := strconv.ParseFloat(valueStr, valueField.Type().Bits())
+		if err != nil {
+			return err
+		}
 		valueField.SetFloat(v)
 	}
+	return nil
 }
 
-func setFieldValue(kind reflect.Kind, valueField reflect.Value, value interface{}) {
-	if valueField.IsValid() && valueField.CanSet() && value != nil {
-		switch kind {
-		case reflect.Bool:
-			var v,ok bool
-			if v,ok = value.(bool);!ok {
-				if valueStr, ok := value.(string); ok && len(valueStr) > 0 {
-					v,_ = strconv.ParseBool(valueStr)
-				}
+// scalarString format the scalar value as string, the floats are formatted without the exponent so the
+// large numbers that are decoded from JSON can be parsed as integers
+func scalarString(rv reflect.Value) string {
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits())
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+// setFieldValue set the value to the field, the string value is converted to the kind of the field
+func setFieldValue(kind reflect.Kind, valueField reflect.Value, value interface{}) error {
+	if !valueField.IsValid() || !valueField.CanSet() || value == nil {
+		return nil
+	}
+	var rv = reflect.ValueOf(value)
+	if rv.Type() == valueField.Type() {
+		valueField.Set(rv)
+		return nil
+	}
+	if _, ok := value.(string); !ok && isScalarKind(kind) && isScalarKind(rv.Kind()) {
+		value = scalarString(rv)
+	}
+	switch kind {
+	case reflect.Bool:
+		if valueStr, ok := value.(string); ok && len(valueStr) > 0 {
+			v, err := strconv.ParseBool(valueStr)
+			if err != nil {
+				return err
 			}
 			valueField.SetBool(v)
-		case reflect.String:
-			if v,ok := value.(string); ok {
-				valueField.SetString(v)
-			}
-		case reflect.Int:
-			var v int
-			var ok bool
-			if v,ok = value.(int); !ok {
-				setFieldInt64(valueField, value)
-			} else {
-				valueField.SetInt(int64(v))
-			}
-		case reflect.Int8:
-			var v int8
-			var ok bool
-			if v,ok = value.(int8); !ok {
-				setFieldInt64(valueField, value)
-			} else {
-				valueField.SetInt(int64(v))
-			}
-		case reflect.Int16:
-			var v int16
-			var ok bool
-			if v,ok = value.(int16); !ok {
-				setFieldInt64(valueField, value)
-			} else {
-				valueField.SetInt(int64(v))
-			}
-		case reflect.Int32:
-			var v int32
-			var ok bool
-			if v,ok = value.(int32); !ok {
-				setFieldInt64(valueField, value)
-			} else {
-				valueField.SetInt(int64(v))
-			}
-		case reflect.Int64:
-			var v int64
-			var ok bool
-			if v,ok = value.(int64); !ok {
-				setFieldInt64(valueField, value)
-			} else {
-				valueField.SetInt(int64(v))
-			}
-		case reflect.Uint:
-			var v uint
-			var ok bool
-			if v,ok = value.(uint); !ok {
-				setFieldUint64(valueField, value)
-			} else {
-				valueField.SetUint(uint64(v))
-			}
-		case reflect.Uint8:
-			var v uint8
-			var ok bool
-			if v,ok = value.(uint8); !ok {
-				setFieldUint64(valueField, value)
-			} else {
-				valueField.SetUint(uint64(v))
-			}
-		case reflect.Uint16:
-			var v uint16
-			var ok bool
-			if v,ok = value.(uint16); !ok {
-				setFieldUint64(valueField, value)
-			} else {
-				valueField.SetUint(uint64(v))
-			}
-		case reflect.Uint32:
-			var v uint32
-			var ok bool
-			if v,ok = value.(uint32); !ok {
-				setFieldUint64(valueField, value)
-			} else {
-				valueField.SetUint(uint64(v))
-			}
-		case reflect.Uint64:
-			var v uint64
-			var ok bool
-			if v,ok = value.(uint64); !ok {
-				setFieldUint64(valueField, value)
-			} else {
-				valueField.SetUint(uint64(v))
-			}
-		case reflect.Float32:
-			var v float32
-			var ok bool
-			if v,ok = value.(float32); !ok {
-				setFieldFloat64(valueField, value)
-			} else {
-				valueField.SetFloat(float64(v))
-			}
-		case reflect.Float64:
-			var v float64
-			var ok bool
-			if v,ok = value.(float64); !ok {
-				setFieldFloat64(valueField, value)
-			} else {
-				valueField.SetFloat(float64(v))
-			}
-		default:
-			valueField.Set(reflect.ValueOf(value))
 		}
+		return nil
+	case reflect.String:
+		if v, ok := value.(string); ok {
+			valueField.SetString(v)
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setFieldInt64(valueField, value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return setFieldUint64(valueField, value)
+	case reflect.Float32, reflect.Float64:
+		return setFieldFloat64(valueField, value)
+	}
+	if !rv.Type().AssignableTo(valueField.Type()) {
+		return errValueType(rv.Type(), valueField.Type())
 	}
-}
\ No newline at end of file
+	valueField.Set(rv)
+	return nil
+}
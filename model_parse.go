@@ -0,0 +1,412 @@
+package wemvc
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxModelSliceIndex the maximum index of the slice field that can be set by the indexed keys like 'Items[0]'
+const maxModelSliceIndex = 1000
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+var timeLayouts = struct {
+	sync.RWMutex
+	layouts []string
+}{layouts: []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}}
+
+// SetTimeLayouts set the layouts that are used to parse the time.Time fields of the models in order.
+// The 'layout' tag of the field takes precedence over the layouts
+func SetTimeLayouts(layouts ...string) {
+	if len(layouts) == 0 {
+		return
+	}
+	timeLayouts.Lock()
+	timeLayouts.layouts = append([]string(nil), layouts...)
+	timeLayouts.Unlock()
+}
+
+func getTimeLayouts() []string {
+	timeLayouts.RLock()
+	defer timeLayouts.RUnlock()
+	return timeLayouts.layouts
+}
+
+// modelValues the values to parse the model, the key is the full name of the field like 'Address.City' or 'Items[0].Name'
+type modelValues map[string][]interface{}
+
+// newModelValues convert the url.Values or the map that has the string keys to model values
+func newModelValues(values interface{}) modelValues {
+	var form url.Values
+	switch v := values.(type) {
+	case url.Values:
+		form = v
+	case *url.Values:
+		if v != nil {
+			form = *v
+		}
+	case map[string][]string:
+		form = v
+	default:
+		rv := reflect.ValueOf(values)
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		var mv = make(modelValues, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			if item := iter.Value(); item.IsValid() && !(item.Kind() == reflect.Interface && item.IsNil()) {
+				mv[iter.Key().String()] = []interface{}{item.Interface()}
+			}
+		}
+		return mv
+	}
+	var mv = make(modelValues, len(form))
+	for key, v := range form {
+		if len(v) == 0 {
+			continue
+		}
+		var items = make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		mv[key] = items
+	}
+	return mv
+}
+
+// present check the key or the sub keys of it exist
+func (mv modelValues) present(key string) bool {
+	if _, ok := mv[key]; ok {
+		return true
+	}
+	for k := range mv {
+		if len(k) > len(key) && strings.HasPrefix(k, key) && (k[len(key)] == '.' || k[len(key)] == '[') {
+			return true
+		}
+	}
+	return false
+}
+
+// subKeys get the sorted names of the sub keys, like 'color' of the key 'Attrs[color]' or 'Attrs.color'.
+// The names in brackets are returned only if brackets is true
+func (mv modelValues) subKeys(key string, brackets bool) []string {
+	var names []string
+	var found = make(map[string]bool)
+	for k := range mv {
+		if len(k) <= len(key)+1 || !strings.HasPrefix(k, key) {
+			continue
+		}
+		var name string
+		switch rest := k[len(key)+1:]; k[len(key)] {
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				continue
+			}
+			name = rest[:end]
+		case '.':
+			if brackets {
+				continue
+			}
+			name = rest
+			if end := strings.IndexAny(rest, ".["); end >= 0 {
+				name = rest[:end]
+			}
+		default:
+			continue
+		}
+		if len(name) > 0 && !found[name] {
+			found[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// modelParser parse the model values to the model and collect the field errors
+type modelParser struct {
+	values modelValues
+	fields []*FieldError
+}
+
+func (p *modelParser) addError(key string, err error) {
+	p.fields = append(p.fields, &FieldError{Field: key, Message: err.Error()})
+}
+
+// parseStruct set the fields of the struct. The fields of the top level model can also be set by the keys that start
+// with the name of the model type, like 'User.Name'
+func (p *modelParser) parseStruct(sValue reflect.Value, prefix string, typeName string) {
+	sType := sValue.Type()
+	for i := 0; i < sType.NumField(); i++ {
+		typeField := sType.Field(i)
+		tagName, _, skip := modelFieldTag(typeField)
+		if skip {
+			continue
+		}
+		if isEmbeddedModel(typeField, tagName) {
+			p.parseEmbedded(sValue.Field(i), prefix, typeName)
+			continue
+		}
+		fieldName := modelFieldName(typeField)
+		if len(typeField.PkgPath) > 0 {
+			continue
+		}
+		var key = strAdd(prefix, fieldName)
+		if len(typeName) > 0 && !p.values.present(key) {
+			key = strAdd(typeName, ".", fieldName)
+		}
+		p.parseField(sValue.Field(i), key, typeField.Tag.Get("layout"))
+	}
+}
+
+// parseEmbedded set the fields of the embedded struct as the fields of the outer struct.
+// The nil pointer of the embedded struct is allocated only if any of the fields is set and the pointer is exported
+func (p *modelParser) parseEmbedded(field reflect.Value, prefix string, typeName string) {
+	if field.Kind() != reflect.Ptr {
+		p.parseStruct(field, prefix, typeName)
+		return
+	}
+	if !field.IsNil() {
+		p.parseStruct(field.Elem(), prefix, typeName)
+		return
+	}
+	if !field.CanSet() {
+		return
+	}
+	var count = len(p.fields)
+	var elem = reflect.New(field.Type().Elem())
+	p.parseStruct(elem.Elem(), prefix, typeName)
+	if len(p.fields) > count || !elem.Elem().IsZero() {
+		field.Set(elem)
+	}
+}
+
+// parseField set the field by the key, nothing is changed if the key and the sub keys of it do not exist
+func (p *modelParser) parseField(field reflect.Value, key string, layout string) {
+	values, ok := p.values[key]
+	if !ok {
+		values, ok = p.values[strAdd(key, "[]")]
+	}
+	if !ok && !p.values.present(key) {
+		return
+	}
+	field = allocField(field)
+	if ok {
+		if err := p.setValue(field, key, values, layout); err != nil {
+			p.addError(key, err)
+		}
+		return
+	}
+	switch {
+	case isTextType(field.Type()):
+	case field.Kind() == reflect.Struct:
+		p.parseStruct(field, strAdd(key, "."), "")
+	case field.Kind() == reflect.Slice:
+		var indexes []int
+		for _, name := range p.values.subKeys(key, true) {
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 {
+				continue
+			}
+			if index > maxModelSliceIndex {
+				p.addError(key, errSliceIndex(index, maxModelSliceIndex))
+				return
+			}
+			indexes = append(indexes, index)
+		}
+		if len(indexes) == 0 {
+			return
+		}
+		sort.Ints(indexes)
+		var length = indexes[len(indexes)-1] + 1
+		if field.Len() < length {
+			var slice = reflect.MakeSlice(field.Type(), length, length)
+			reflect.Copy(slice, field)
+			field.Set(slice)
+		}
+		for _, index := range indexes {
+			p.parseField(field.Index(index), strAdd(key, "[", strconv.Itoa(index), "]"), layout)
+		}
+	case field.Kind() == reflect.Map:
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		var mType = field.Type()
+		for _, name := range p.values.subKeys(key, false) {
+			var mapKey = reflect.New(mType.Key()).Elem()
+			if err := setScalarValue(mapKey, name, ""); err != nil {
+				p.addError(strAdd(key, "[", name, "]"), err)
+				continue
+			}
+			var subKey = strAdd(key, "[", name, "]")
+			if !p.values.present(subKey) {
+				subKey = strAdd(key, ".", name)
+			}
+			var elem = reflect.New(mType.Elem()).Elem()
+			if existing := field.MapIndex(mapKey); existing.IsValid() {
+				elem.Set(existing)
+			}
+			p.parseField(elem, subKey, layout)
+			field.SetMapIndex(mapKey, elem)
+		}
+	}
+}
+
+// setValue set the field by the values of the key. The repeated values are set to the slice field, and the nested map
+// value like map[string]interface{} is parsed to the struct or map field
+func (p *modelParser) setValue(field reflect.Value, key string, values []interface{}, layout string) error {
+	var first = values[0]
+	var rv = reflect.ValueOf(first)
+	if rv.Type().AssignableTo(field.Type()) {
+		field.Set(rv)
+		return nil
+	}
+	if isTextType(field.Type()) {
+		return setScalarValue(field, first, layout)
+	}
+	switch field.Kind() {
+	case reflect.Struct:
+		if rv.Kind() != reflect.Map {
+			return errValueType(rv.Type(), field.Type())
+		}
+		var sub = &modelParser{values: newModelValues(first)}
+		sub.parseStruct(field, "", "")
+		for _, f := range sub.fields {
+			f.Field = strAdd(key, ".", f.Field)
+			p.fields = append(p.fields, f)
+		}
+		return nil
+	case reflect.Map:
+		if rv.Kind() != reflect.Map {
+			return errValueType(rv.Type(), field.Type())
+		}
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		for iter := rv.MapRange(); iter.Next(); {
+			var mapKey = reflect.New(field.Type().Key()).Elem()
+			var subKey = strAdd(key, "[", fmt.Sprint(iter.Key().Interface()), "]")
+			if err := setScalarValue(mapKey, iter.Key().Interface(), ""); err != nil {
+				p.addError(subKey, err)
+				continue
+			}
+			var item = iter.Value()
+			if item.Kind() == reflect.Interface && item.IsNil() {
+				continue
+			}
+			var elem = reflect.New(field.Type().Elem()).Elem()
+			if err := p.setValue(allocField(elem), subKey, []interface{}{item.Interface()}, layout); err != nil {
+				p.addError(subKey, err)
+				continue
+			}
+			field.SetMapIndex(mapKey, elem)
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if str, ok := first.(string); ok && len(values) == 1 && field.Type().Elem().Kind() == reflect.Uint8 && field.Kind() == reflect.Slice {
+			field.SetBytes([]byte(str))
+			return nil
+		}
+		if len(values) == 1 && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) {
+			values = make([]interface{}, rv.Len())
+			for i := range values {
+				values[i] = rv.Index(i).Interface()
+			}
+		}
+		if field.Kind() == reflect.Array {
+			if len(values) > field.Len() {
+				return errSliceIndex(len(values)-1, field.Len()-1)
+			}
+		} else {
+			field.Set(reflect.MakeSlice(field.Type(), len(values), len(values)))
+		}
+		for i, value := range values {
+			if value == nil {
+				continue
+			}
+			if err := p.setValue(allocField(field.Index(i)), key, []interface{}{value}, layout); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setScalarValue(field, first, layout)
+}
+
+// setScalarValue set the scalar, time.Time or encoding.TextUnmarshaler field by the value
+func setScalarValue(field reflect.Value, value interface{}, layout string) error {
+	field = allocField(field)
+	var rv = reflect.ValueOf(value)
+	if rv.Type().AssignableTo(field.Type()) {
+		field.Set(rv)
+		return nil
+	}
+	str, isStr := value.(string)
+	if field.Type() == timeType {
+		if !isStr {
+			return errValueType(rv.Type(), field.Type())
+		}
+		if len(str) == 0 {
+			return nil
+		}
+		t, err := parseTime(str, layout)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		if !isStr {
+			return errValueType(rv.Type(), field.Type())
+		}
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str))
+	}
+	if !isScalarKind(field.Kind()) {
+		return errValueType(rv.Type(), field.Type())
+	}
+	return setFieldValue(field.Kind(), field, value)
+}
+
+// parseTime parse the time by the layout of the field, or the layouts set by SetTimeLayouts
+func parseTime(value string, layout string) (time.Time, error) {
+	if len(layout) > 0 {
+		return time.Parse(layout, value)
+	}
+	var layouts = getTimeLayouts()
+	var err error
+	for _, l := range layouts {
+		var t time.Time
+		if t, err = time.Parse(l, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errTimeFormat(value)
+}
+
+// allocField allocate the nil pointers of the field and get the value that the pointers point to
+func allocField(field reflect.Value) reflect.Value {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	return field
+}
+
+// isTextType check the type is set from a single text value rather than the sub keys
+func isTextType(t reflect.Type) bool {
+	return t == timeType || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
@@ -0,0 +1,171 @@
+package wemvc
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testItem struct {
+	Name  string
+	Count int
+}
+
+type testParseModel struct {
+	Name     *string
+	Address  testAddress `json:"address"`
+	Items    []testItem
+	Tags     []string
+	Scores   []int
+	Attrs    map[string]string
+	Counts   map[string]int
+	Born     time.Time `layout:"02/01/2006"`
+	Created  *time.Time
+	IP       net.IP
+	Children []*testItem
+}
+
+func Test_ModelParse(t *testing.T) {
+	var values = url.Values{
+		"Name":             {"steve"},
+		"address.city":     {"Shanghai"},
+		"Items[1].Name":    {"b"},
+		"Items[0].Name":    {"a"},
+		"Items[0].Count":   {"2"},
+		"Tags":             {"x", "y"},
+		"Scores[]":         {"1", "2", "3"},
+		"Attrs[color]":     {"red"},
+		"Attrs.size":       {"xl"},
+		"Born":             {"24/12/1990"},
+		"Created":          {"2020-01-02 03:04:05"},
+		"IP":               {"10.0.0.1"},
+		"Children[0].Name": {"c"},
+	}
+	var m testParseModel
+	if err := ModelParse(&m, values); err != nil {
+		t.Fatal(err)
+	}
+	if m.Name == nil || *m.Name != "steve" || m.Address.City != "Shanghai" {
+		t.Error("test 1 failed", m)
+	}
+	if len(m.Items) != 2 || m.Items[0].Name != "a" || m.Items[0].Count != 2 || m.Items[1].Name != "b" {
+		t.Error("test 2 failed", m.Items)
+	}
+	if len(m.Tags) != 2 || len(m.Scores) != 3 || m.Scores[2] != 3 || m.Attrs["color"] != "red" || m.Attrs["size"] != "xl" {
+		t.Error("test 3 failed", m.Tags, m.Scores, m.Attrs)
+	}
+	if m.Born.Year() != 1990 || m.Born.Month() != 12 || m.Created == nil || m.Created.Hour() != 3 {
+		t.Error("test 4 failed", m.Born, m.Created)
+	}
+	if m.IP.String() != "10.0.0.1" || len(m.Children) != 1 || m.Children[0].Name != "c" {
+		t.Error("test 5 failed", m.IP, m.Children)
+	}
+
+	m = testParseModel{}
+	var data = map[string]interface{}{
+		"address": map[string]interface{}{"city": "Beijing"},
+		"Items":   []interface{}{map[string]interface{}{"Name": "a", "Count": 3.0}},
+		"Counts":  map[string]interface{}{"a": 1.0, "b": "x"},
+	}
+	err := ModelParse(&m, data)
+	if m.Address.City != "Beijing" || len(m.Items) != 1 || m.Items[0].Count != 3 || m.Counts["a"] != 1 {
+		t.Error("test 6 failed", m)
+	}
+	if bindErr, ok := err.(*BindError); !ok || len(bindErr.Fields) != 1 || bindErr.Fields[0].Field != "Counts[b]" {
+		t.Error("test 7 failed", err)
+	}
+
+	// the large numbers that are decoded from JSON
+	var decoded map[string]interface{}
+	if err = json.Unmarshal([]byte(`{"Items":[{"Count":1000000}],"Scores":[2500000]}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	m = testParseModel{}
+	if err = ModelParse(&m, decoded); err != nil || len(m.Items) != 1 || m.Items[0].Count != 1000000 ||
+		len(m.Scores) != 1 || m.Scores[0] != 2500000 {
+		t.Error("test 8 failed", err, m.Items, m.Scores)
+	}
+	if err = ModelParse(&m, map[string]interface{}{"Scores": []interface{}{1.5}}); err == nil {
+		t.Error("test 9 failed")
+	}
+
+	err = ModelParse(&m, url.Values{"Items[0].Count": {"abc"}, "Born": {"1990-12-24"}, "IP": {"x"}, "Tags[5000]": {"a"}})
+	if bindErr, ok := err.(*BindError); !ok || len(bindErr.Fields) != 4 {
+		t.Error("test 10 failed", err)
+	}
+	if err = ModelParse(m, values); err != errBindModel {
+		t.Error("test 11 failed", err)
+	}
+
+	// the integers are parsed in base 10
+	var n struct {
+		I int
+		U uint
+	}
+	if err = ModelParse(&n, url.Values{"I": {"010"}, "U": {"08"}}); err != nil || n.I != 10 || n.U != 8 {
+		t.Error("test 12 failed", err, n)
+	}
+}
+
+type testBaseModel struct {
+	ID      int    `json:"id"`
+	Created string `json:"created,omitempty"`
+}
+
+type testMapModel struct {
+	*testBaseModel
+	testItem
+	Name     string       `json:"name"`
+	Count    int          `json:"count,omitempty"`
+	Secret   string       `json:"-"`
+	Dash     string       `json:"-,"`
+	Address  *testAddress `json:"address,omitempty"`
+	Items    []testItem
+	Born     time.Time
+	internal string
+}
+
+func Test_Model2Map(t *testing.T) {
+	if Model2Map(nil) != nil || Model2Map((*testMapModel)(nil)) != nil || Model2Map(12) != nil {
+		t.Error("test 1 failed")
+	}
+	var m = &testMapModel{
+		testBaseModel: &testBaseModel{ID: 12},
+		testItem:      testItem{Name: "embedded", Count: 3},
+		Name:          "steve",
+		Secret:        "x",
+		Dash:          "y",
+		Address:       &testAddress{City: "Shanghai"},
+		Items:         []testItem{{Name: "a", Count: 1}},
+		Born:          time.Date(1990, 12, 24, 0, 0, 0, 0, time.UTC),
+		internal:      "z",
+	}
+	var data = Model2Map(m)
+	if data["id"] != 12 || data["name"] != "steve" || data["Count"] != 3 {
+		t.Error("test 2 failed", data)
+	}
+	for _, key := range []string{"created", "count", "Secret", "internal", "testItem", "testBaseModel"} {
+		if _, ok := data[key]; ok {
+			t.Error("test 3 failed", key)
+		}
+	}
+	if data["-"] != "y" || data["address"].(map[string]interface{})["city"] != "Shanghai" {
+		t.Error("test 4 failed", data)
+	}
+	if items, ok := data["Items"].([]interface{}); !ok || items[0].(map[string]interface{})["Name"] != "a" {
+		t.Error("test 5 failed", data["Items"])
+	}
+
+	// the nil pointer of the unexported embedded struct cannot be allocated
+	var parsed = testMapModel{testBaseModel: &testBaseModel{}}
+	if err := ModelParse(&parsed, data); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.ID != 12 || parsed.Name != "steve" || parsed.testItem.Count != 3 ||
+		parsed.Dash != "y" || len(parsed.Secret) != 0 || parsed.Address.City != "Shanghai" ||
+		len(parsed.Items) != 1 || parsed.Items[0].Count != 1 || !parsed.Born.Equal(m.Born) {
+		t.Error("test 6 failed", parsed)
+	}
+}
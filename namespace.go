@@ -15,7 +15,7 @@ type nsSettingGroup struct {
 // NsSection the namespace section
 type NsSection struct {
 	name     string
-	server   *server
+	server   *Application
 	settings map[string]string
 	viewContainer
 	filterContainer
@@ -33,6 +33,49 @@ func (ns *NsSection) Dir() string {
 
 // Route add the route to namespace
 func (ns *NsSection) Route(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, nil, c, defaultAction...)
+}
+
+// RouteGet add the route that only accepts the GET (and HEAD) requests to namespace
+func (ns *NsSection) RouteGet(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, []string{"GET"}, c, defaultAction...)
+}
+
+// RoutePost add the route that only accepts the POST requests to namespace
+func (ns *NsSection) RoutePost(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, []string{"POST"}, c, defaultAction...)
+}
+
+// RoutePut add the route that only accepts the PUT requests to namespace
+func (ns *NsSection) RoutePut(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, []string{"PUT"}, c, defaultAction...)
+}
+
+// RouteDelete add the route that only accepts the DELETE requests to namespace
+func (ns *NsSection) RouteDelete(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, []string{"DELETE"}, c, defaultAction...)
+}
+
+// RoutePatch add the route that only accepts the PATCH requests to namespace
+func (ns *NsSection) RoutePatch(routePath string, c interface{}, defaultAction ...string) {
+	ns.RouteMethods(routePath, []string{"PATCH"}, c, defaultAction...)
+}
+
+// RouteNamed add the route with a name to namespace, the URL of the route can be generated by URLFor
+func (ns *NsSection) RouteNamed(name, routePath string, c interface{}, defaultAction ...string) {
+	if len(name) == 0 {
+		panic(errRouteNameEmpty)
+	}
+	ns.addRoute(name, routePath, nil, c, defaultAction...)
+}
+
+// RouteMethods add the route that only accepts the http methods to namespace
+func (ns *NsSection) RouteMethods(routePath string, methods []string, c interface{}, defaultAction ...string) {
+	ns.addRoute("", routePath, methods, c, defaultAction...)
+}
+
+func (ns *NsSection) addRoute(name, routePath string, methods []string, c interface{}, defaultAction ...string) {
+	ns.server.assertNotLocked()
 	if !strings.HasPrefix(routePath, "/") {
 		routePath = strAdd("/", routePath)
 	}
@@ -42,7 +85,7 @@ func (ns *NsSection) Route(routePath string, c interface{}, defaultAction ...str
 	if len(defaultAction) > 0 && len(defaultAction[0]) > 0 {
 		action = defaultAction[0]
 	}
-	ns.server.addRoute(nsName, routePath, c, action)
+	ns.server.addRoute(name, nsName, routePath, c, action, methods)
 }
 
 // SetPathFilter add the context filter to namespace
@@ -53,11 +96,7 @@ func (ns *NsSection) SetPathFilter(pathPrefix string, filter CtxFilter) {
 	if !strings.HasSuffix(pathPrefix, "/") {
 		pathPrefix = strAdd(pathPrefix, "/")
 	}
-	prefix := strAdd(ns.name, pathPrefix)
-	if !ns.server.routing.MatchCase {
-		prefix = strings.ToLower(prefix)
-	}
-	ns.addFilter(prefix, filter)
+	ns.addFilter(strAdd(ns.name, pathPrefix), filter)
 }
 
 // GetSetting get the setting from the config file by name
@@ -0,0 +1,221 @@
+package wemvc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formatter format the data as the response body of the media type that the formatter is registered with
+type Formatter func(data interface{}) ([]byte, error)
+
+// mediaFormatter the formatter that is registered with the content type
+type mediaFormatter struct {
+	contentType string
+	mediaType   string
+	format      Formatter
+}
+
+// acceptRange the media range in the 'Accept' header like 'text/html;q=0.8'
+type acceptRange struct {
+	mainType string
+	subType  string
+	params   map[string]string
+	q        float64
+}
+
+func formatJSON(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func formatXML(data interface{}) ([]byte, error) {
+	return xml.Marshal(data)
+}
+
+func formatText(data interface{}) ([]byte, error) {
+	return str2Byte(fmt.Sprint(data)), nil
+}
+
+// defaultFormatters the formatters of JSON, XML and plain text. The JSON formatter is used if the request accepts any type
+func defaultFormatters() []*mediaFormatter {
+	return []*mediaFormatter{
+		{contentType: "application/json; charset=utf-8", mediaType: "application/json", format: formatJSON},
+		{contentType: "application/xml; charset=utf-8", mediaType: "application/xml", format: formatXML},
+		{contentType: "text/xml; charset=utf-8", mediaType: "text/xml", format: formatXML},
+		{contentType: "text/plain; charset=utf-8", mediaType: "text/plain", format: formatText},
+	}
+}
+
+// regFormatter register the formatter of the content type like 'text/csv; charset=utf-8',
+// the formatter of the same media type is replaced
+func (app *Application) regFormatter(contentType string, f Formatter) {
+	app.assertNotLocked()
+	if f == nil {
+		panic(errFormatterNil)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || strings.Contains(mediaType, "*") {
+		panic(errInvalidMediaType(contentType))
+	}
+	var mf = &mediaFormatter{contentType: contentType, mediaType: mediaType, format: f}
+	for i, existing := range app.formatters {
+		if existing.mediaType == mediaType {
+			app.formatters[i] = mf
+			return
+		}
+	}
+	app.formatters = append(app.formatters, mf)
+}
+
+// parseAccept parse the 'Accept' header, the media ranges are sorted by the quality value and the specificity
+func parseAccept(accept string) []*acceptRange {
+	var ranges []*acceptRange
+	for _, item := range strings.Split(accept, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(item)
+		if err != nil {
+			continue
+		}
+		var slash = strings.IndexByte(mediaType, '/')
+		if slash <= 0 || slash == len(mediaType)-1 {
+			if mediaType != "*" {
+				continue
+			}
+			mediaType, slash = "*/*", 1
+		}
+		var r = &acceptRange{mainType: mediaType[:slash], subType: mediaType[slash+1:], q: 1}
+		if r.mainType == "*" && r.subType != "*" {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil || value < 0 || value > 1 {
+				continue
+			}
+			r.q = value
+			delete(params, "q")
+		}
+		if len(params) > 0 {
+			r.params = params
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// specificity get the specificity of the media range, the more specific range takes precedence
+func (r *acceptRange) specificity() int {
+	switch {
+	case r.mainType == "*":
+		return 0
+	case r.subType == "*":
+		return 1
+	}
+	return 2 + len(r.params)
+}
+
+// match check the media range matches the media type and the parameters of the content type
+func (r *acceptRange) match(mediaType string, params map[string]string) bool {
+	if r.mainType != "*" {
+		var slash = strings.IndexByte(mediaType, '/')
+		if mediaType[:slash] != r.mainType || (r.subType != "*" && mediaType[slash+1:] != r.subType) {
+			return false
+		}
+	}
+	for k, v := range r.params {
+		if !strings.EqualFold(params[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// quality get the quality value of the content type by the most specific media range that matches it
+func quality(ranges []*acceptRange, contentType string) float64 {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0
+	}
+	var q, specificity = 0.0, -1
+	for _, r := range ranges {
+		if s := r.specificity(); s > specificity && r.match(mediaType, params) {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// negotiate get the formatters that the request accepts, sorted by the quality value. The formatters of the same
+// quality value are in the registered order, and all the formatters are acceptable if the request has no 'Accept' header
+func (app *Application) negotiate(req *http.Request) []*mediaFormatter {
+	var accept = strings.Join(req.Header.Values("Accept"), ",")
+	if len(strings.TrimSpace(accept)) == 0 {
+		return app.formatters
+	}
+	var ranges = parseAccept(accept)
+	var acceptable []*mediaFormatter
+	var qs = make(map[*mediaFormatter]float64)
+	for _, f := range app.formatters {
+		if q := quality(ranges, f.contentType); q > 0 {
+			acceptable = append(acceptable, f)
+			qs[f] = q
+		}
+	}
+	sort.SliceStable(acceptable, func(i, j int) bool {
+		return qs[acceptable[i]] > qs[acceptable[j]]
+	})
+	return acceptable
+}
+
+// NegotiateResult the result that formats the data by the formatter that the request accepts. The next acceptable
+// formatter is tried if the data can not be formatted, and the response is 406 Not Acceptable if no formatter is acceptable
+type NegotiateResult struct {
+	Data       interface{}
+	StatusCode int
+	app        *Application
+}
+
+// ExecResult execute the negotiate result
+func (nr *NegotiateResult) ExecResult(w http.ResponseWriter, r *http.Request) {
+	var app = nr.app
+	if app == nil {
+		app = defaultApp
+	}
+	w.Header().Add("Vary", "Accept")
+	var formatters = app.negotiate(r)
+	if len(formatters) == 0 {
+		app.handleErrorReq(r, 406).ExecResult(w, r)
+		return
+	}
+	var formatErr error
+	for _, f := range formatters {
+		data, err := f.format(nr.Data)
+		if err != nil {
+			if formatErr == nil {
+				formatErr = err
+			}
+			continue
+		}
+		w.Header().Set("Content-Type", f.contentType)
+		if nr.StatusCode > 0 && nr.StatusCode != 200 {
+			w.WriteHeader(nr.StatusCode)
+		}
+		w.Write(data)
+		return
+	}
+	panic(formatErr)
+}
@@ -0,0 +1,112 @@
+package wemvc
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type testNegotiateModel struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func Test_Application_negotiate(t *testing.T) {
+	app := New(os.TempDir())
+	app.RegFormatter("text/csv; charset=utf-8", func(data interface{}) ([]byte, error) {
+		return []byte(strAdd("name\n", data.(*testNegotiateModel).Name)), nil
+	})
+	var data = &testNegotiateModel{Name: "steve"}
+	var tests = []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"name":"steve"}`},
+		{"*/*", "application/json; charset=utf-8", `{"name":"steve"}`},
+		{"text/xml", "text/xml; charset=utf-8", `<testNegotiateModel><name>steve</name></testNegotiateModel>`},
+		{"application/json;q=0.5, application/xml", "application/xml; charset=utf-8", ""},
+		{"text/*;q=0.9, text/csv;q=0.1, application/json;q=0.5", "text/xml; charset=utf-8", ""},
+		{"text/csv", "text/csv; charset=utf-8", "name\nsteve"},
+		{"text/html, */*;q=0.1", "application/json; charset=utf-8", ""},
+		{"image/png", "", ""},
+		{"application/json;q=0, text/plain;q=0.2", "text/plain; charset=utf-8", "&{steve}"},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if len(test.accept) > 0 {
+			req.Header.Set("Accept", test.accept)
+		}
+		w := httptest.NewRecorder()
+		(&NegotiateResult{Data: data, app: app}).ExecResult(w, req)
+		if len(test.contentType) == 0 {
+			if w.Code != 406 {
+				t.Error("test", i, "failed", w.Code)
+			}
+			continue
+		}
+		if w.Code != 200 || w.Header().Get("Content-Type") != test.contentType || w.Header().Get("Vary") != "Accept" {
+			t.Error("test", i, "failed", w.Code, w.Header())
+		}
+		if len(test.body) > 0 && strings.TrimSpace(w.Body.String()) != test.body {
+			t.Error("test", i, "failed", w.Body.String())
+		}
+	}
+}
+
+type testNegotiateCtrl struct {
+	Controller
+}
+
+func (ctrl testNegotiateCtrl) GetImplicit() interface{} {
+	return &testNegotiateModel{Name: "steve"}
+}
+
+func (ctrl testNegotiateCtrl) GetNegotiate() interface{} {
+	return ctrl.Negotiate(map[string]string{"name": "steve"})
+}
+
+func Test_Application_implicitResult(t *testing.T) {
+	app := New(os.TempDir())
+	app.Route("/<action>", testNegotiateCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	var jsonBody, xmlBody = `{"name":"steve"}`, `<testNegotiateModel><name>steve</name></testNegotiateModel>`
+	var tests = []struct {
+		url         string
+		accept      string
+		cType       string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/implicit", "", "", 200, "application/json; charset=utf-8", jsonBody},
+		{"/implicit", "*/*", "", 200, "application/json; charset=utf-8", jsonBody},
+		{"/implicit", "application/xml", "", 200, "application/xml; charset=utf-8", xmlBody},
+		// the content type of the request does not change the format of the response
+		{"/implicit", "application/json", "text/xml", 200, "application/json; charset=utf-8", jsonBody},
+		{"/implicit", "text/html", "", 406, "", ""},
+		// the map can not be formatted as XML, so the next acceptable formatter is used
+		{"/negotiate", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "", 200, "application/json; charset=utf-8", jsonBody},
+		{"/negotiate", "text/html", "", 406, "", ""},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", test.url, nil)
+		if len(test.accept) > 0 {
+			req.Header.Set("Accept", test.accept)
+		}
+		if len(test.cType) > 0 {
+			req.Header.Set("Content-Type", test.cType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != test.code || (test.code == 200 && (w.Header().Get("Content-Type") != test.contentType ||
+			strings.TrimSpace(w.Body.String()) != test.body)) {
+			t.Error("test", i, "failed", w.Code, w.Header(), w.Body.String())
+		}
+	}
+}
@@ -20,7 +20,7 @@ type FileResult struct {
 // ExecResult execute the result
 func (fr *FileResult) ExecResult(w http.ResponseWriter, r *http.Request) {
 	if len(fr.ContentType) > 0 {
-		w.Header().Add("Content-Type", fr.ContentType)
+		w.Header().Set("Content-Type", fr.ContentType)
 	}
 	http.ServeFile(w, r, fr.FilePath)
 }
@@ -33,20 +33,25 @@ type RedirectResult struct {
 
 // ExecResult execute the redirect result
 func (rr *RedirectResult) ExecResult(w http.ResponseWriter, r *http.Request) {
-	var statusCode = 301
-	if rr.StatusCode != 301 {
+	var statusCode = rr.StatusCode
+	switch statusCode {
+	case 301, 302, 303, 307, 308:
+	default:
 		statusCode = 302
 	}
 	http.Redirect(w, r, rr.RedirectURL, statusCode)
 }
 
-// ContentResult define the action result struct
+// ContentResult define the action result struct.
+// The weak ETag of the content is generated if AutoETag is true, and the 304 response is returned if the content
+// is not modified by the 'If-None-Match' or the 'If-Modified-Since' header of the request
 type ContentResult struct {
 	Writer      *bytes.Buffer
 	StatusCode  int
 	ContentType string
 	Encoding    string
 	Headers     map[string]string
+	AutoETag    bool
 }
 
 // Header get the content result header
@@ -99,6 +104,16 @@ func (cr *ContentResult) ExecResult(w http.ResponseWriter, r *http.Request) {
 			w.Header().Add(k, v)
 		}
 	}
+	output := cr.Output()
+	if cr.StatusCode == 200 {
+		if cr.AutoETag && len(w.Header().Get("ETag")) == 0 {
+			w.Header().Set("ETag", weakETag(output))
+		}
+		if notModified(r, w.Header()) {
+			writeNotModified(w)
+			return
+		}
+	}
 	if len(cr.ContentType) > 0 {
 		encoding := cr.Encoding
 		if len(encoding) == 0 {
@@ -110,7 +125,6 @@ func (cr *ContentResult) ExecResult(w http.ResponseWriter, r *http.Request) {
 	if cr.StatusCode != 200 {
 		w.WriteHeader(cr.StatusCode)
 	}
-	output := cr.Output()
 	if len(output) > 0 {
 		w.Write(output)
 	}
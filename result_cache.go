@@ -0,0 +1,106 @@
+package wemvc
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weakETag generate the weak entity tag of the content like 'W/"1f-8c3e5a0d2b7f4e19"'
+func weakETag(content []byte) string {
+	var h = fnv.New64a()
+	h.Write(content)
+	return strAdd("W/\"", strconv.FormatInt(int64(len(content)), 16), "-", strconv.FormatUint(h.Sum64(), 16), "\"")
+}
+
+// etagMatch check the entity tags match by the weak comparison
+func etagMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// notModified check the resource is not modified by the 'If-None-Match' and the 'If-Modified-Since' headers of the
+// request and the 'ETag' and the 'Last-Modified' headers of the response. The 'If-Modified-Since' header is ignored
+// if the request has the 'If-None-Match' header
+func notModified(req *http.Request, header http.Header) bool {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	if inm := req.Header.Get("If-None-Match"); len(inm) > 0 {
+		var etag = header.Get("ETag")
+		if len(etag) == 0 {
+			return false
+		}
+		for _, item := range strings.Split(inm, ",") {
+			item = strings.TrimSpace(item)
+			if item == "*" || etagMatch(item, etag) {
+				return true
+			}
+		}
+		return false
+	}
+	var ims = req.Header.Get("If-Modified-Since")
+	var lastModified = header.Get("Last-Modified")
+	if len(ims) == 0 || len(lastModified) == 0 {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// writeNotModified write the 304 response, the headers of the content are removed
+func writeNotModified(w http.ResponseWriter) {
+	var header = w.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// SetLastModified set the 'Last-Modified' header of the response. The content result returns 304 Not Modified
+// if the request has the 'If-Modified-Since' header and the content is not modified since then
+func (ctrl *Controller) SetLastModified(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	ctrl.Response().Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// SetETag set the 'ETag' header of the response, the tag is quoted if it is not.
+// The content result returns 304 Not Modified if the tag matches the 'If-None-Match' header of the request
+func (ctrl *Controller) SetETag(etag string) {
+	if len(etag) == 0 {
+		return
+	}
+	if !strings.HasSuffix(etag, "\"") {
+		etag = strAdd("\"", etag, "\"")
+	}
+	ctrl.Response().Header().Set("ETag", etag)
+}
+
+// SetCacheControl set the 'Cache-Control' header of the response like 'public, max-age=3600' or 'no-cache'
+func (ctrl *Controller) SetCacheControl(value string) {
+	ctrl.Response().Header().Set("Cache-Control", value)
+}
+
+// NotModified check the resource is not modified by the 'ETag' and the 'Last-Modified' headers that have been set,
+// so the action can skip rendering the content and return the NotModifiedResult
+func (ctrl *Controller) NotModified() bool {
+	return notModified(ctrl.Request(), ctrl.Response().Header())
+}
+
+// NotModifiedResult the result of 304 Not Modified
+type NotModifiedResult struct{}
+
+// ExecResult execute the not modified result
+func (nm *NotModifiedResult) ExecResult(w http.ResponseWriter, r *http.Request) {
+	writeNotModified(w)
+}
@@ -0,0 +1,96 @@
+package wemvc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_ContentResult_etag(t *testing.T) {
+	var newResult = func() *ContentResult {
+		var result = NewResult()
+		result.AutoETag = true
+		result.Write([]byte("<h1>hello</h1>"))
+		return result
+	}
+	w := httptest.NewRecorder()
+	newResult().ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	var etag = w.Header().Get("ETag")
+	if w.Code != 200 || len(etag) == 0 || etag[:3] != `W/"` || w.Body.String() != "<h1>hello</h1>" {
+		t.Fatal("test 1 failed", w.Code, etag)
+	}
+	var tests = []struct {
+		method string
+		header string
+		value  string
+		code   int
+	}{
+		{"GET", "If-None-Match", etag, 304},
+		{"HEAD", "If-None-Match", `"other", ` + etag[2:], 304},
+		{"GET", "If-None-Match", "*", 304},
+		{"GET", "If-None-Match", `W/"other"`, 200},
+		{"POST", "If-None-Match", etag, 200},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(test.method, "/", nil)
+		req.Header.Set(test.header, test.value)
+		w = httptest.NewRecorder()
+		newResult().ExecResult(w, req)
+		if w.Code != test.code || (test.code == 304 && (w.Body.Len() > 0 || len(w.Header().Get("Content-Type")) > 0)) {
+			t.Error("test", i+2, "failed", w.Code)
+		}
+	}
+}
+
+type testCacheCtrl struct {
+	Controller
+}
+
+var testModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func (c testCacheCtrl) Index() interface{} {
+	c.SetLastModified(testModTime)
+	c.SetCacheControl("private, max-age=60")
+	if c.NotModified() {
+		return &NotModifiedResult{}
+	}
+	return c.PlainText("content")
+}
+
+func Test_Controller_lastModified(t *testing.T) {
+	app := New(os.TempDir())
+	app.UseETag()
+	app.Route("/", testCacheCtrl{})
+	h, err := app.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Shutdown(context.Background())
+	var tests = []struct {
+		since time.Time
+		code  int
+	}{
+		{time.Time{}, 200},
+		{testModTime, 304},
+		{testModTime.Add(time.Hour), 304},
+		{testModTime.Add(-time.Hour), 200},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if !test.since.IsZero() {
+			req.Header.Set("If-Modified-Since", test.since.Format(http.TimeFormat))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != test.code || w.Header().Get("Last-Modified") != testModTime.Format(http.TimeFormat) ||
+			w.Header().Get("Cache-Control") != "private, max-age=60" {
+			t.Error("test", i+1, "failed", w.Code, w.Header())
+		}
+		if test.code == 200 && (w.Body.String() != "content" || len(w.Header().Get("ETag")) == 0) {
+			t.Error("test", i+1, "failed", w.Body.String(), w.Header())
+		}
+	}
+}
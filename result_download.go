@@ -0,0 +1,136 @@
+package wemvc
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DownloadResult the result that serves the content of the file path, the io.ReadSeeker or the data.
+// The range requests and the conditional requests are supported by http.ServeContent.
+// The content is served as attachment with the file name unless Inline is true, and the Reader is closed if it is an
+// io.Closer
+type DownloadResult struct {
+	FileName     string
+	ContentType  string
+	FilePath     string
+	Reader       io.ReadSeeker
+	Data         []byte
+	ModTime      time.Time
+	Inline       bool
+	CacheControl string
+	Headers      map[string]string
+	app          *Application
+}
+
+// ExecResult execute the download result
+func (dr *DownloadResult) ExecResult(w http.ResponseWriter, r *http.Request) {
+	if closer, ok := dr.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var app = dr.app
+	if app == nil {
+		app = defaultApp
+	}
+	var content = dr.Reader
+	var modTime = dr.ModTime
+	var name = dr.FileName
+	switch {
+	case content != nil:
+	case len(dr.FilePath) > 0:
+		f, err := os.Open(dr.FilePath)
+		if err != nil {
+			app.handleErrorReq(r, 404).ExecResult(w, r)
+			return
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err != nil || stat.IsDir() {
+			app.handleErrorReq(r, 404).ExecResult(w, r)
+			return
+		}
+		if modTime.IsZero() {
+			modTime = stat.ModTime()
+		}
+		if len(name) == 0 {
+			name = filepath.Base(dr.FilePath)
+		}
+		content = f
+	default:
+		content = bytes.NewReader(dr.Data)
+	}
+	for k, v := range dr.Headers {
+		w.Header().Set(k, v)
+	}
+	if len(dr.ContentType) > 0 {
+		w.Header().Set("Content-Type", dr.ContentType)
+	}
+	if len(dr.CacheControl) > 0 {
+		w.Header().Set("Cache-Control", dr.CacheControl)
+	}
+	var disposition = "attachment"
+	if dr.Inline {
+		disposition = "inline"
+	}
+	if len(name) > 0 || !dr.Inline {
+		w.Header().Set("Content-Disposition", contentDisposition(disposition, name))
+	}
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// contentDisposition get the Content-Disposition header value with the file name encoded by RFC 6266.
+// The 'filename' parameter is the ASCII fallback, and the 'filename*' parameter is the UTF-8 encoded file name
+func contentDisposition(disposition, fileName string) string {
+	if len(fileName) == 0 {
+		return disposition
+	}
+	var fallback strings.Builder
+	var plain = true
+	for _, c := range fileName {
+		switch {
+		case c == '"' || c == '\\':
+			fallback.WriteByte('_')
+			plain = false
+		case c < 0x20 || c == 0x7f:
+			plain = false
+		case c > 0x7e:
+			fallback.WriteByte('_')
+			plain = false
+		default:
+			fallback.WriteRune(c)
+		}
+	}
+	var value = strAdd(disposition, "; filename=\"", fallback.String(), "\"")
+	if !plain {
+		value = strAdd(value, "; filename*=UTF-8''", encodeExtValue(fileName))
+	}
+	return value
+}
+
+// encodeExtValue percent-encode the value except the 'attr-char' of RFC 5987
+func encodeExtValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAttrChar(c) {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteByte('%')
+		buf.WriteByte(hex[c>>4])
+		buf.WriteByte(hex[c&15])
+	}
+	return buf.String()
+}
+
+func isAttrChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
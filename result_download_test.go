@@ -0,0 +1,104 @@
+package wemvc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_contentDisposition(t *testing.T) {
+	var tests = map[string]string{
+		"":               "attachment",
+		"report.csv":     `attachment; filename="report.csv"`,
+		`a"b.txt`:        `attachment; filename="a_b.txt"; filename*=UTF-8''a%22b.txt`,
+		"报告 2020.pdf":    `attachment; filename="__ 2020.pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%202020.pdf`,
+		"naïve file.txt": `attachment; filename="na_ve file.txt"; filename*=UTF-8''na%C3%AFve%20file.txt`,
+	}
+	for name, expected := range tests {
+		if v := contentDisposition("attachment", name); v != expected {
+			t.Error("failed", name, v)
+		}
+	}
+}
+
+func Test_DownloadResult(t *testing.T) {
+	var modTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var result = &DownloadResult{Data: []byte("0123456789"), FileName: "data.txt", ModTime: modTime, CacheControl: "private, max-age=60"}
+	w := httptest.NewRecorder()
+	result.ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 200 || w.Body.String() != "0123456789" || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" ||
+		w.Header().Get("Cache-Control") != "private, max-age=60" || w.Header().Get("Content-Disposition") != `attachment; filename="data.txt"` {
+		t.Error("test 1 failed", w.Code, w.Header())
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	w = httptest.NewRecorder()
+	result.ExecResult(w, req)
+	if w.Code != 206 || w.Body.String() != "234" {
+		t.Error("test 2 failed", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+	w = httptest.NewRecorder()
+	result.ExecResult(w, req)
+	if w.Code != 304 {
+		t.Error("test 3 failed", w.Code)
+	}
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var file = filepath.Join(dir, "export.json")
+	ioutil.WriteFile(file, []byte(`{"a":1}`), 0644)
+	w = httptest.NewRecorder()
+	(&DownloadResult{FilePath: file, Inline: true}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != `{"a":1}` || w.Header().Get("Content-Disposition") != `inline; filename="export.json"` ||
+		len(w.Header().Get("Last-Modified")) == 0 {
+		t.Error("test 4 failed", w.Header())
+	}
+	w = httptest.NewRecorder()
+	(&DownloadResult{FilePath: filepath.Join(dir, "missing")}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 404 {
+		t.Error("test 5 failed", w.Code)
+	}
+
+	// the missing file and the directory are handled by the error handler of the app
+	app := New(os.TempDir())
+	app.HandleError(404, func(req *http.Request) *ContentResult {
+		return &ContentResult{Writer: bytes.NewBufferString("custom 404"), StatusCode: 404, ContentType: "text/plain"}
+	})
+	for i, path := range []string{filepath.Join(dir, "missing"), dir} {
+		w = httptest.NewRecorder()
+		(&DownloadResult{FilePath: path, app: app}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != 404 || w.Body.String() != "custom 404" {
+			t.Error("test", i+6, "failed", w.Code, w.Body.String())
+		}
+	}
+
+	var reader = &testReadSeekCloser{Reader: strings.NewReader("data")}
+	w = httptest.NewRecorder()
+	(&DownloadResult{Reader: reader, FileName: "data.txt"}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "data" || !reader.closed {
+		t.Error("test 8 failed", w.Body.String())
+	}
+}
+
+type testReadSeekCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (r *testReadSeekCloser) Close() error {
+	r.closed = true
+	return nil
+}
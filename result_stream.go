@@ -0,0 +1,217 @@
+package wemvc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const streamBufferSize = 32 * 1024
+
+// StreamWriter the writer of the stream result, the written data is sent to the client by Flush
+type StreamWriter struct {
+	w       http.ResponseWriter
+	req     *http.Request
+	flusher http.Flusher
+	written bool
+}
+
+func newStreamWriter(w http.ResponseWriter, req *http.Request) *StreamWriter {
+	var sw = &StreamWriter{w: w, req: req}
+	sw.flusher, _ = w.(http.Flusher)
+	return sw
+}
+
+// Write write the data to the response
+func (sw *StreamWriter) Write(data []byte) (int, error) {
+	sw.written = true
+	return sw.w.Write(data)
+}
+
+// Flush send the written data to the client if the response writer supports http.Flusher
+func (sw *StreamWriter) Flush() {
+	if sw.flusher != nil {
+		sw.written = true
+		sw.flusher.Flush()
+	}
+}
+
+// Done get the channel that is closed when the client closes the connection or the request is canceled
+func (sw *StreamWriter) Done() <-chan struct{} {
+	return sw.req.Context().Done()
+}
+
+// StreamResult the result that writes the response body without buffering it.
+// The body is copied from the Reader, or written by the Writer function if the Reader is nil.
+// The response is sent in chunks if the response writer supports http.Flusher
+type StreamResult struct {
+	StatusCode  int
+	ContentType string
+	Headers     map[string]string
+	Reader      io.Reader
+	Writer      func(w *StreamWriter) error
+}
+
+// ExecResult execute the stream result
+func (sr *StreamResult) ExecResult(w http.ResponseWriter, r *http.Request) {
+	if closer, ok := sr.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	for k, v := range sr.Headers {
+		w.Header().Set(k, v)
+	}
+	if len(sr.ContentType) > 0 {
+		w.Header().Set("Content-Type", sr.ContentType)
+	}
+	if sr.StatusCode > 0 && sr.StatusCode != 200 {
+		w.WriteHeader(sr.StatusCode)
+	}
+	var sw = newStreamWriter(w, r)
+	var err error
+	if sr.Reader != nil {
+		err = copyStream(sw, sr.Reader)
+	} else if sr.Writer != nil {
+		err = sr.Writer(sw)
+	}
+	if err != nil && !sw.written && sr.StatusCode <= 0 {
+		w.WriteHeader(500)
+	}
+}
+
+// copyStream copy the reader to the stream writer, the data is flushed after each read
+func copyStream(sw *StreamWriter, reader io.Reader) error {
+	var buf = make([]byte, streamBufferSize)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if _, wErr := sw.Write(buf[:n]); wErr != nil {
+				return wErr
+			}
+			sw.Flush()
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-sw.Done():
+			return sw.req.Context().Err()
+		default:
+		}
+	}
+}
+
+// SSEvent the event of the server-sent events. The Data is written as it is if it is a string or []byte,
+// otherwise it is encoded as JSON
+type SSEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// writeTo write the event in the format of the 'text/event-stream'
+func (e *SSEvent) writeTo(w io.Writer) error {
+	var buf strings.Builder
+	if len(e.ID) > 0 {
+		buf.WriteString(strAdd("id: ", sseLine(e.ID), "\n"))
+	}
+	if len(e.Event) > 0 {
+		buf.WriteString(strAdd("event: ", sseLine(e.Event), "\n"))
+	}
+	if e.Retry > 0 {
+		buf.WriteString(strAdd("retry: ", strconv.FormatInt(int64(e.Retry/time.Millisecond), 10), "\n"))
+	}
+	var data string
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = byte2Str(d)
+	default:
+		bytes, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = byte2Str(bytes)
+	}
+	if e.Data != nil {
+		data = strings.Replace(data, "\r\n", "\n", -1)
+		for _, line := range strings.Split(data, "\n") {
+			buf.WriteString(strAdd("data: ", line, "\n"))
+		}
+	}
+	buf.WriteString("\n")
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// sseLine remove the line breaks from the id and event fields
+func sseLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// SSEResult the result of the server-sent events. The events are sent until the Events channel is closed or the
+// client closes the connection. A comment line is sent as the heartbeat if no event is sent in the Heartbeat duration
+type SSEResult struct {
+	Events    <-chan *SSEvent
+	Heartbeat time.Duration
+	Headers   map[string]string
+}
+
+// ExecResult execute the server-sent events result
+func (sr *SSEResult) ExecResult(w http.ResponseWriter, r *http.Request) {
+	for k, v := range sr.Headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	var sw = newStreamWriter(w, r)
+	sw.Flush()
+	var heartbeat <-chan time.Time
+	var timer *time.Timer
+	if sr.Heartbeat > 0 {
+		timer = time.NewTimer(sr.Heartbeat)
+		defer timer.Stop()
+		heartbeat = timer.C
+	}
+	for {
+		select {
+		case e, ok := <-sr.Events:
+			if !ok {
+				return
+			}
+			if e == nil {
+				continue
+			}
+			if err := e.writeTo(sw); err != nil {
+				return
+			}
+		case <-heartbeat:
+			if _, err := io.WriteString(sw, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-sw.Done():
+			return
+		}
+		sw.Flush()
+		if timer != nil {
+			// the heartbeat is sent only if nothing is sent in the Heartbeat duration
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(sr.Heartbeat)
+		}
+	}
+}
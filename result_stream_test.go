@@ -0,0 +1,81 @@
+package wemvc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_StreamResult(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&StreamResult{Reader: strings.NewReader("hello stream"), ContentType: "text/plain"}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "hello stream" || w.Header().Get("Content-Type") != "text/plain" || !w.Flushed {
+		t.Error("test 1 failed", w.Body.String(), w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	(&StreamResult{Writer: func(sw *StreamWriter) error {
+		for i := 0; i < 3; i++ {
+			io.WriteString(sw, "row\n")
+			sw.Flush()
+		}
+		return nil
+	}}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "row\nrow\nrow\n" || !w.Flushed {
+		t.Error("test 2 failed", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	(&StreamResult{Writer: func(sw *StreamWriter) error {
+		return errors.New("failed")
+	}}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 500 {
+		t.Error("test 3 failed", w.Code)
+	}
+}
+
+func Test_SSEResult(t *testing.T) {
+	var events = make(chan *SSEvent, 3)
+	events <- &SSEvent{ID: "1", Event: "update", Data: "line1\nline2", Retry: 3 * time.Second}
+	events <- &SSEvent{Data: map[string]int{"count": 2}}
+	close(events)
+	w := httptest.NewRecorder()
+	(&SSEResult{Events: events}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	var expected = "id: 1\nevent: update\nretry: 3000\ndata: line1\ndata: line2\n\ndata: {\"count\":2}\n\n"
+	if w.Body.String() != expected || w.Header().Get("Content-Type") != "text/event-stream; charset=utf-8" {
+		t.Error("test 1 failed", w.Body.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	w = httptest.NewRecorder()
+	(&SSEResult{Events: make(chan *SSEvent), Heartbeat: 10 * time.Millisecond}).ExecResult(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if !strings.HasPrefix(w.Body.String(), ": heartbeat\n\n") {
+		t.Error("test 2 failed", w.Body.String())
+	}
+
+	// the heartbeat is not sent while the events are sent, unless the events are delayed by the slow machine
+	events = make(chan *SSEvent)
+	var maxGap time.Duration
+	go func() {
+		var last = time.Now()
+		for i := 0; i < 40; i++ {
+			events <- &SSEvent{Data: "tick"}
+			if gap := time.Since(last); gap > maxGap {
+				maxGap = gap
+			}
+			last = time.Now()
+			time.Sleep(5 * time.Millisecond)
+		}
+		close(events)
+	}()
+	w = httptest.NewRecorder()
+	(&SSEResult{Events: events, Heartbeat: 100 * time.Millisecond}).ExecResult(w, httptest.NewRequest("GET", "/", nil))
+	if strings.Count(w.Body.String(), "data: tick") != 40 || maxGap < 50*time.Millisecond && strings.Contains(w.Body.String(), "heartbeat") {
+		t.Error("test 3 failed", maxGap, w.Body.String())
+	}
+}
@@ -0,0 +1,175 @@
+package wemvc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// routeEntry a route in the route tree, the nodes are the path from the first depth to the leaf
+type routeEntry struct {
+	nodes []*routeNode
+	ctrl  *controllerInfo
+}
+
+func (entry *routeEntry) path() string {
+	var paths = make([]string, 0, len(entry.nodes))
+	for _, node := range entry.nodes {
+		paths = append(paths, node.Path)
+	}
+	return strAdd("/", strings.Join(paths, "/"))
+}
+
+// hasActionParam check the route has 'action' param. The lookup of this kind of route fails
+// when the controller has no such action, so that the routes after it can still be reached
+func (entry *routeEntry) hasActionParam() bool {
+	for _, node := range entry.nodes {
+		if _, ok := node.Params["action"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (entry *routeEntry) hasDefaultValue() bool {
+	for _, node := range entry.nodes {
+		for _, opt := range node.Params {
+			if opt.HasDefaultValue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptMethods check the route accepts all the methods that are accepted by the other route
+func (entry *routeEntry) acceptMethods(other *routeEntry) bool {
+	if len(entry.ctrl.Methods) == 0 {
+		return true
+	}
+	if len(other.ctrl.Methods) == 0 {
+		return false
+	}
+	for _, m := range other.ctrl.Methods {
+		var found = false
+		for _, m1 := range entry.ctrl.Methods {
+			if m == m1 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// collectRoutes get all the routes in the tree in the order of the lookup
+func (tree *routeTree) collectRoutes(node *routeNode, parents []*routeNode, entries []*routeEntry) []*routeEntry {
+	var nodes = append(append([]*routeNode{}, parents...), node)
+	if node.CtrlInfo != nil {
+		entries = append(entries, &routeEntry{nodes: nodes, ctrl: node.CtrlInfo})
+	}
+	var added = make(map[*controllerInfo]bool)
+	for _, c := range node.MethodCtrl {
+		if !added[c] {
+			added[c] = true
+			entries = append(entries, &routeEntry{nodes: nodes, ctrl: c})
+		}
+	}
+	for _, child := range node.Children {
+		entries = tree.collectRoutes(child, nodes, entries)
+	}
+	return entries
+}
+
+// segmentKey get the key of the node that ignores the param names,
+// the nodes with the same key match the same url segments
+func (tree *routeTree) segmentKey(node *routeNode) string {
+	if node.NodeType == rtStatic {
+		if !tree.MatchCase {
+			return strings.ToLower(node.Path)
+		}
+		return node.Path
+	}
+	if node.NodeType != rtParam {
+		return node.Path
+	}
+	var key string
+	for _, p := range node.PathSplits {
+		if !tree.isParamPath(p) {
+			if !tree.MatchCase {
+				p = strings.ToLower(p)
+			}
+			key = strAdd(key, p)
+			continue
+		}
+		opt := node.Params[p[1:len(p)-1]]
+		key = strAdd(key, fmt.Sprintf("<%s(%s)%d~%d,%d~%d>", opt.Validation, opt.Setting, opt.MinLength, opt.MaxLength, opt.MinValue, opt.MaxValue))
+	}
+	return key
+}
+
+// segmentCovers check all the url segments matched by node2 are also matched by node1
+func (tree *routeTree) segmentCovers(node1, node2 *routeNode) bool {
+	if node2.NodeType == rtCatchAll {
+		return node1.NodeType == rtCatchAll
+	}
+	if tree.segmentKey(node1) == tree.segmentKey(node2) {
+		return true
+	}
+	if node1.NodeType == rtParam && len(node1.PathSplits) == 1 {
+		opt := node1.Params[node1.PathSplits[0][1:len(node1.PathSplits[0])-1]]
+		return opt.Validation == "any" && opt.MinLength <= 1 && opt.MaxLength == 255
+	}
+	return false
+}
+
+// routeCovers check all the urls matched by route2 are also matched by route1
+func (tree *routeTree) routeCovers(route1, route2 *routeEntry) bool {
+	var nodes1 = route1.nodes
+	var nodes2 = route2.nodes
+	if nodes1[len(nodes1)-1].NodeType == rtCatchAll && nodes2[len(nodes2)-1].NodeType != rtCatchAll {
+		if len(nodes2) < len(nodes1) {
+			return false
+		}
+		nodes1 = nodes1[:len(nodes1)-1]
+		nodes2 = nodes2[:len(nodes1)]
+	} else if len(nodes1) != len(nodes2) {
+		return false
+	}
+	for i := range nodes1 {
+		if !tree.segmentCovers(nodes1[i], nodes2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkConflicts check the ambiguous routes and the routes that can never be reached,
+// because all the urls of the route are matched by a route that is looked up before it
+func (tree *routeTree) checkConflicts() error {
+	var entries []*routeEntry
+	for _, child := range tree.Children {
+		entries = tree.collectRoutes(child, nil, entries)
+	}
+	for i, route2 := range entries {
+		if route2.hasDefaultValue() {
+			continue
+		}
+		for _, route1 := range entries[:i] {
+			// the controllers of the same node are selected by the http method
+			if route1.nodes[len(route1.nodes)-1] == route2.nodes[len(route2.nodes)-1] {
+				continue
+			}
+			if route1.hasActionParam() || !route1.acceptMethods(route2) || !tree.routeCovers(route1, route2) {
+				continue
+			}
+			if tree.routeCovers(route2, route1) {
+				return fmt.Errorf("The route '%s' is ambiguous with the route '%s'", route2.path(), route1.path())
+			}
+			return fmt.Errorf("The route '%s' is unreachable, it is shadowed by the route '%s'", route2.path(), route1.path())
+		}
+	}
+	return nil
+}
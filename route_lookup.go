@@ -0,0 +1,157 @@
+package wemvc
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// routeLookup the state of a route lookup. The route params are pushed to the state when the route node is matched
+// and popped when the lookup goes back, so the route data map is only created once the route is found.
+// The states are pooled and reused by the lookups
+type routeLookup struct {
+	urlParts     []string
+	pathLength   uint16
+	method       string
+	endWithSlash bool
+	keys         []string
+	values       []string
+	allowed      map[string]bool
+	// fixCase the url parts are fixed to the letter case of the route, the fixed parts are stored in fixParts
+	fixCase  bool
+	fixParts []string
+	// foldBuf the buffer of the lower case url part that is used to find the static child case-insensitively
+	foldBuf []byte
+}
+
+// routeMatch the result of the route lookup
+type routeMatch struct {
+	ctrl      *controllerInfo
+	routeData map[string]string
+	// cleanPath the url path without the empty parts and the trailing slash.
+	// The letter case of the url path is fixed by the route if the case fixing is required by the lookup
+	cleanPath string
+	// slashDiff the url path has trailing slash or empty parts
+	slashDiff bool
+	// caseDiff the letter case of the url path is different from the route
+	caseDiff bool
+}
+
+var lookupPool = sync.Pool{
+	New: func() interface{} {
+		return &routeLookup{
+			urlParts: make([]string, 0, 16),
+			keys:     make([]string, 0, 8),
+			values:   make([]string, 0, 8),
+			foldBuf:  make([]byte, 0, 64),
+		}
+	},
+}
+
+func getLookup(method string) *routeLookup {
+	var l = lookupPool.Get().(*routeLookup)
+	l.method = method
+	return l
+}
+
+func putLookup(l *routeLookup) {
+	// clear the references to the url path before the state is reused
+	for i := range l.urlParts {
+		l.urlParts[i] = ""
+	}
+	for i := range l.fixParts {
+		l.fixParts[i] = ""
+	}
+	l.reset(0)
+	l.urlParts = l.urlParts[:0]
+	l.fixParts = l.fixParts[:0]
+	l.fixCase = false
+	l.pathLength = 0
+	l.method = ""
+	l.endWithSlash = false
+	for m := range l.allowed {
+		delete(l.allowed, m)
+	}
+	lookupPool.Put(l)
+}
+
+// mark get the count of the route params, it is used to reset the state if the route node does not match
+func (l *routeLookup) mark() int {
+	return len(l.keys)
+}
+
+func (l *routeLookup) reset(mark int) {
+	for i := mark; i < len(l.keys); i++ {
+		l.keys[i] = ""
+		l.values[i] = ""
+	}
+	l.keys = l.keys[:mark]
+	l.values = l.values[:mark]
+}
+
+func (l *routeLookup) push(key, value string) {
+	l.keys = append(l.keys, key)
+	l.values = append(l.values, value)
+}
+
+func (l *routeLookup) get(key string) (string, bool) {
+	for i, k := range l.keys {
+		if k == key {
+			return l.values[i], true
+		}
+	}
+	return "", false
+}
+
+// lower write the lower case url part to the buffer of the state. False is returned if the part is not ascii,
+// the buffer is only valid until the next call
+func (l *routeLookup) lower(part string) ([]byte, bool) {
+	l.foldBuf = l.foldBuf[:0]
+	for i := 0; i < len(part); i++ {
+		var c = part[i]
+		if c >= utf8.RuneSelf {
+			return nil, false
+		}
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		l.foldBuf = append(l.foldBuf, c)
+	}
+	return l.foldBuf, true
+}
+
+// fix set the url part of the depth in the letter case of the route
+func (l *routeLookup) fix(depth uint16, part string) {
+	if l.fixCase {
+		l.fixParts[depth] = part
+	}
+}
+
+// pathInfo get the url path from the depth, which is used as the value of the '*pathInfo' param
+func (l *routeLookup) pathInfo(depth uint16) string {
+	var path = strings.Join(l.urlParts[depth:], "/")
+	if l.endWithSlash {
+		path = strAdd(path, "/")
+	}
+	return path
+}
+
+// ctrlFor get the controller info of the node that handles the http method of the lookup
+func (l *routeLookup) ctrlFor(node *routeNode) *controllerInfo {
+	if l.allowed == nil && node.CtrlInfo == nil && len(node.MethodCtrl) > 0 {
+		l.allowed = make(map[string]bool)
+	}
+	return node.ctrlFor(l.method, l.allowed)
+}
+
+// routeData create the route data map from the route params, nil is returned if there is no route param
+func (l *routeLookup) routeData() map[string]string {
+	if len(l.keys) == 0 {
+		return nil
+	}
+	var data = make(map[string]string, len(l.keys))
+	for i, k := range l.keys {
+		data[k] = l.values[i]
+	}
+	return data
+}
@@ -3,6 +3,7 @@ package wemvc
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -29,6 +30,9 @@ type RouteOption struct {
 	Setting         string
 	MaxLength       uint8
 	MinLength       uint8
+	MinValue        int64
+	MaxValue        int64
+	Regexp          *regexp.Regexp `json:"-"`
 }
 
 type routeNode struct {
@@ -39,7 +43,12 @@ type routeNode struct {
 	PathSplits []string
 	Params     map[string]*RouteOption
 	CtrlInfo   *controllerInfo
+	MethodCtrl map[string]*controllerInfo
 	Children   []*routeNode
+
+	staticIndex map[string]int // the index of the static children by path
+	foldIndex   map[string]int // the index of the static children by lower case path
+	dynIndex    []int          // the index of the param and catch-all children
 }
 
 func (node *routeNode) isLeaf() bool {
@@ -65,25 +74,128 @@ func (node *routeNode) findChild(path string) *routeNode {
 	return nil
 }
 
+// appendChild append the child node and index it. The static children are indexed by the path,
+// so the lookup does not need to compare the path with each of them
+func (node *routeNode) appendChild(child *routeNode) {
+	var index = len(node.Children)
+	node.Children = append(node.Children, child)
+	if child.NodeType != rtStatic {
+		node.dynIndex = append(node.dynIndex, index)
+		return
+	}
+	if node.staticIndex == nil {
+		node.staticIndex = make(map[string]int)
+		node.foldIndex = make(map[string]int)
+	}
+	node.staticIndex[child.Path] = index
+	var lowerPath = strings.ToLower(child.Path)
+	if _, ok := node.foldIndex[lowerPath]; !ok {
+		node.foldIndex[lowerPath] = index
+	}
+}
+
+// staticChild get the index of the static child that matches the url path part, or -1 if not found.
+// The url path part is lower cased into the buffer of the lookup state, so the case-insensitive lookup does not allocate
+func (node *routeNode) staticChild(part string, matchCase bool, l *routeLookup) int {
+	var index int
+	var ok bool
+	if matchCase {
+		index, ok = node.staticIndex[part]
+	} else if lower, ascii := l.lower(part); ascii {
+		index, ok = node.foldIndex[string(lower)]
+	} else {
+		index, ok = node.foldIndex[strings.ToLower(part)]
+	}
+	if !ok {
+		return -1
+	}
+	return index
+}
+
+func (node *routeNode) hasCtrl() bool {
+	return node.CtrlInfo != nil || len(node.MethodCtrl) > 0
+}
+
+// addCtrl add the controller info to the node. The controller info without http method constraint
+// handles all the methods that are not handled by the method specified controller info
+func (node *routeNode) addCtrl(ctrlInfo *controllerInfo) error {
+	if len(ctrlInfo.Methods) == 0 {
+		if node.CtrlInfo != nil {
+			return fmt.Errorf("Duplicate controller info in route tree. Path: %s, Depth: %d",
+				node.Path,
+				node.CurDepth)
+		}
+		node.CtrlInfo = ctrlInfo
+		return nil
+	}
+	for _, m := range ctrlInfo.Methods {
+		if err := node.addMethodCtrl(m, ctrlInfo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (node *routeNode) addMethodCtrl(method string, ctrlInfo *controllerInfo) error {
+	if _, ok := node.MethodCtrl[method]; ok {
+		return fmt.Errorf("Duplicate controller info for method %s in route tree. Path: %s, Depth: %d",
+			method,
+			node.Path,
+			node.CurDepth)
+	}
+	if node.MethodCtrl == nil {
+		node.MethodCtrl = make(map[string]*controllerInfo)
+	}
+	node.MethodCtrl[method] = ctrlInfo
+	return nil
+}
+
+// ctrlFor get the controller info that handles the http method.
+// If the node has controller info but none of them accepts the method, the accepted methods are added to 'allowed'
+func (node *routeNode) ctrlFor(method string, allowed map[string]bool) *controllerInfo {
+	if len(node.MethodCtrl) > 0 {
+		var upperMethod = upperMethod(method)
+		if c, ok := node.MethodCtrl[upperMethod]; ok {
+			return c
+		}
+		if c, ok := node.MethodCtrl["GET"]; ok && upperMethod == "HEAD" {
+			return c
+		}
+		if node.CtrlInfo == nil && allowed != nil {
+			for m := range node.MethodCtrl {
+				allowed[m] = true
+				if m == "GET" {
+					allowed["HEAD"] = true
+				}
+			}
+		}
+	}
+	return node.CtrlInfo
+}
+
 func (node *routeNode) addChild(childNode *routeNode) error {
 	if childNode == nil {
 		return errors.New("'childNode' parameter cannot be nil")
 	}
 	var existChild = node.findChild(childNode.Path)
 	if existChild == nil {
-		node.Children = append(node.Children, childNode)
+		node.appendChild(childNode)
 		return nil
 	}
 	if childNode.MaxDepth > existChild.MaxDepth {
 		existChild.MaxDepth = childNode.MaxDepth
 	}
 	if childNode.isLeaf() {
-		if existChild.CtrlInfo != nil {
-			return fmt.Errorf("Duplicate controller info in route tree. Path: %s, Depth: %d",
-				existChild.Path,
-				existChild.CurDepth)
+		if childNode.CtrlInfo != nil {
+			if err := existChild.addCtrl(childNode.CtrlInfo); err != nil {
+				return err
+			}
+		}
+		for m, c := range childNode.MethodCtrl {
+			if err := existChild.addMethodCtrl(m, c); err != nil {
+				return err
+			}
 		}
-		existChild.CtrlInfo = childNode.CtrlInfo
 	} else {
 		for _, child := range childNode.Children {
 			err := existChild.addChild(child)
@@ -95,48 +207,24 @@ func (node *routeNode) addChild(childNode *routeNode) error {
 	return nil
 }
 
-func (node *routeNode) isParamPath(path string) bool {
-	return strings.HasPrefix(path, rtParamBeginStr) && strings.HasSuffix(path, rtParamEndStr)
-}
-
-func (node *routeNode) detectDefault(method string) (bool, *controllerInfo, map[string]string) {
-	if !node.hasChildren() {
-		return false, nil, nil
-	}
-	for _, child := range node.Children {
-		if child.NodeType != rtParam || len(child.PathSplits) != 1 || !node.isParamPath(child.PathSplits[0]) {
-			continue
-		}
-		paramName := ""
-		var opt *RouteOption
-		for name, o := range child.Params {
-			paramName = name
-			opt = o
-			break
-		}
-		if !opt.HasDefaultValue {
-			continue
-		}
-		if child.CtrlInfo != nil {
-			if paramName == "action" {
-				if action := child.CtrlInfo.findActionName(opt.DefaultValue, method, app.friendlyActionName); len(action)  == 0 {
-					return false, nil, nil
-				}
-			}
-			return true, child.CtrlInfo, map[string]string{paramName: opt.DefaultValue}
-		}
-		found, ctrl, routeMap := child.detectDefault(method)
-		if found {
-			if paramName == "action" {
-				if action := ctrl.findActionName(opt.DefaultValue, method, app.friendlyActionName); len(action) == 0 {
-					return false, nil, nil
-				}
+// hasStar check the '*' character is used out of the catch-all node and the route param options
+func (node *routeNode) hasStar() bool {
+	switch node.NodeType {
+	case rtCatchAll:
+		return false
+	case rtParam:
+		for _, p := range node.PathSplits {
+			if !node.isParamPath(p) && strings.Contains(p, "*") {
+				return true
 			}
-			routeMap[paramName] = opt.DefaultValue
-			return true, ctrl, routeMap
 		}
+		return false
 	}
-	return false, nil, nil
+	return strings.Contains(node.Path, "*")
+}
+
+func (node *routeNode) isParamPath(path string) bool {
+	return strings.HasPrefix(path, rtParamBeginStr) && strings.HasSuffix(path, rtParamEndStr)
 }
 
 func newRouteNode(routePath string, ctrlInfo *controllerInfo) (*routeNode, error) {
@@ -176,17 +264,19 @@ func newRouteNode(routePath string, ctrlInfo *controllerInfo) (*routeNode, error
 			result = child
 			current = result
 		} else {
-			current.Children = []*routeNode{child}
-			current = current.Children[0]
+			current.appendChild(child)
+			current = child
 		}
 	}
-	current.CtrlInfo = ctrlInfo
+	if ctrlInfo != nil {
+		current.addCtrl(ctrlInfo)
+	}
 	current = result
 	for {
 		if current == nil {
 			break
 		}
-		if strings.Contains(current.Path, "*") && current.NodeType != rtCatchAll {
+		if current.hasStar() {
 			return nil, errors.New(strAdd("Invalid URL route parameter '", current.Path, "'"))
 		}
 		if current.NodeType == rtCatchAll && len(current.Children) > 0 {
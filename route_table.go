@@ -0,0 +1,95 @@
+package wemvc
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo the route information in the route table
+type RouteInfo struct {
+	Name          string            `json:"name,omitempty"`
+	Pattern       string            `json:"pattern"`
+	Namespace     string            `json:"namespace,omitempty"`
+	Controller    string            `json:"controller"`
+	Actions       []string          `json:"actions"`
+	DefaultAction string            `json:"defaultAction"`
+	Methods       []string          `json:"methods,omitempty"`
+	Defaults      map[string]string `json:"defaults,omitempty"`
+}
+
+// RouteTable the route table of the application
+type RouteTable []*RouteInfo
+
+// String render the route table as text
+func (rt RouteTable) String() string {
+	var buf = &bytes.Buffer{}
+	w := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMETHODS\tPATTERN\tNAMESPACE\tCONTROLLER\tACTIONS\tDEFAULTS")
+	for _, info := range rt {
+		methods := "ANY"
+		if len(info.Methods) > 0 {
+			methods = strings.Join(info.Methods, ",")
+		}
+		var defaults []string
+		for key, value := range info.Defaults {
+			defaults = append(defaults, strAdd(key, "=", value))
+		}
+		sort.Strings(defaults)
+		if _, ok := info.Defaults["action"]; !ok {
+			defaults = append([]string{strAdd("action=", info.DefaultAction)}, defaults...)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.Name,
+			methods,
+			info.Pattern,
+			info.Namespace,
+			info.Controller,
+			strings.Join(info.Actions, ","),
+			strings.Join(defaults, ","))
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// JSON render the route table as json
+func (rt RouteTable) JSON() []byte {
+	return data2Json(rt)
+}
+
+func (r *routeConfig) routeInfo(friendlyAction bool) (*RouteInfo, error) {
+	cInfo := r.genCtrlInfo(friendlyAction)
+	info := &RouteInfo{
+		Name:          r.name,
+		Pattern:       r.routePath,
+		Namespace:     r.namespace,
+		Controller:    cInfo.CtrlType.String(),
+		DefaultAction: cInfo.DefaultAction,
+		Methods:       r.methods,
+	}
+	for action := range cInfo.Actions {
+		info.Actions = append(info.Actions, action)
+	}
+	sort.Strings(info.Actions)
+	if r.routePath == "/" {
+		return info, nil
+	}
+	node, err := newRouteNode(r.routePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	for ; node != nil; node = node.firstChild() {
+		for name, opt := range node.Params {
+			if !opt.HasDefaultValue {
+				continue
+			}
+			if info.Defaults == nil {
+				info.Defaults = make(map[string]string)
+			}
+			info.Defaults[name] = opt.DefaultValue
+		}
+	}
+	return info, nil
+}
@@ -2,8 +2,9 @@ package wemvc
 
 import (
 	"reflect"
-	"testing"
+	"strconv"
 	"strings"
+	"testing"
 )
 
 func Test_splitUrlPath(t *testing.T) {
@@ -195,3 +196,268 @@ func Test_checkParamOption(t *testing.T) {
 		t.Error("test 1 failed")
 	}
 }
+
+func Test_routeTree_methods(t *testing.T) {
+	var getCtrl = newCtrlInfo()
+	getCtrl.Methods = []string{"GET"}
+	var postCtrl = newCtrlInfo()
+	postCtrl.Methods = []string{"POST", "PUT"}
+	var root = newRouteTree()
+	if err := root.addRoute("/user/<id:int>", getCtrl); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.addRoute("/user/<id:int>", postCtrl); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.addRoute("/user/<id:int>", postCtrl); err == nil {
+		t.Error("test 1 failed")
+	}
+	if c, _, err := root.lookup("/user/12", "get"); err != nil || c != getCtrl {
+		t.Error("test 2 failed")
+	}
+	if c, _, err := root.lookup("/user/12", "head"); err != nil || c != getCtrl {
+		t.Error("test 3 failed")
+	}
+	if c, _, err := root.lookup("/user/12", "put"); err != nil || c != postCtrl {
+		t.Error("test 4 failed")
+	}
+	c, _, err := root.lookup("/user/12", "delete")
+	errMethod, ok := err.(*errMethodNotAllowed)
+	if c != nil || !ok || strings.Join(errMethod.allowed, ",") != "GET,HEAD,POST,PUT" {
+		t.Error("test 5 failed")
+	}
+	if c, _, err := root.lookup("/user/abc", "delete"); c != nil || err != nil {
+		t.Error("test 6 failed")
+	}
+}
+
+func Test_routeTree_buildURL(t *testing.T) {
+	var root = newRouteTree()
+	if u, err := root.buildURL("/user/<id:int>/<action=detail>", map[string]string{"id": "12"}); err != nil || u != "/user/12/detail" {
+		t.Error("test 1 failed", u, err)
+	}
+	if _, err := root.buildURL("/user/<id:int>", map[string]string{"id": "abc"}); err == nil {
+		t.Error("test 2 failed")
+	}
+	if _, err := root.buildURL("/user/<id:int>", nil); err == nil {
+		t.Error("test 3 failed")
+	}
+	if u, err := root.buildURL("/blog/<title>", map[string]string{"title": "a b", "page": "2"}); err != nil || u != "/blog/a%20b?page=2" {
+		t.Error("test 4 failed", u, err)
+	}
+	if u, err := root.buildURL("/files/*pathInfo", map[string]string{"pathInfo": "css/site.css"}); err != nil || u != "/files/css/site.css" {
+		t.Error("test 5 failed", u, err)
+	}
+	if u, err := root.buildURL("/edit-<name:word>.html", map[string]string{"name": "steve"}); err != nil || u != "/edit-steve.html" {
+		t.Error("test 6 failed", u, err)
+	}
+}
+
+func Test_analyzeParamOption_regex(t *testing.T) {
+	paths, opts, err := analyzeParamOption("<slug:regex([a-z0-9-]+)>")
+	if err != nil || len(paths) != 1 || paths[0] != "<slug>" {
+		t.Fatal("test 1 failed", err)
+	}
+	if opts["slug"].Validation != "regex" || opts["slug"].Regexp == nil || opts["slug"].Setting != "[a-z0-9-]+" {
+		t.Error("test 2 failed")
+	}
+	if _, _, err = analyzeParamOption("<slug:regex([a-z)>"); err == nil {
+		t.Error("test 3 failed")
+	}
+	if _, _, err = analyzeParamOption("<time:regex(\\d{2}:\\d{2})>"); err != nil {
+		t.Error("test 4 failed", err)
+	}
+	if _, _, err = analyzeParamOption("<slug:word:any>"); err == nil {
+		t.Error("test 5 failed")
+	}
+	if _, _, err = analyzeParamOption("<slug:regex()>"); err == nil {
+		t.Error("test 6 failed")
+	}
+}
+
+func Test_routeTree_regex(t *testing.T) {
+	var ctrlInfo = newCtrlInfo()
+	var root = newRouteTree()
+	if err := root.addRoute("/blog/<slug:regex([a-z0-9-]+)>", ctrlInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.addRoute("/tag/<name:regex([a-z]*x)>-<id:int>", ctrlInfo); err != nil {
+		t.Fatal(err)
+	}
+	if c, rd, _ := root.lookup("/blog/hello-world-2", "get"); c == nil || rd["slug"] != "hello-world-2" {
+		t.Error("test 1 failed")
+	}
+	if c, _, _ := root.lookup("/blog/Hello", "get"); c != nil {
+		t.Error("test 2 failed")
+	}
+	if c, _, _ := root.lookup("/blog/hello_world", "get"); c != nil {
+		t.Error("test 3 failed")
+	}
+	if c, rd, _ := root.lookup("/tag/box-12", "get"); c == nil || rd["name"] != "box" || rd["id"] != "12" {
+		t.Error("test 4 failed")
+	}
+	// the match that is longer than the max length is not truncated to uint8
+	if c, _, _ := root.lookup("/blog/"+strings.Repeat("a", 257), "get"); c != nil {
+		t.Error("test 5 failed")
+	}
+}
+
+func Test_routeTree_checkConflicts(t *testing.T) {
+	var newTree = func(paths ...string) *routeTree {
+		var root = newRouteTree()
+		for _, p := range paths {
+			if err := root.addRoute(p, newCtrlInfo()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return root
+	}
+	if err := newTree("/user/<id:int>", "/user/<uid:int>").checkConflicts(); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Error("test 1 failed", err)
+	}
+	if err := newTree("/<name>", "/about").checkConflicts(); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Error("test 2 failed", err)
+	}
+	if err := newTree("/files/*pathInfo", "/files/css/<name>").checkConflicts(); err == nil {
+		t.Error("test 3 failed")
+	}
+	if err := newTree("/about", "/<name>", "/user/<id:int>", "/user/<name:word>").checkConflicts(); err != nil {
+		t.Error("test 4 failed", err)
+	}
+	if err := newTree("/<action>", "/about").checkConflicts(); err != nil {
+		t.Error("test 5 failed", err)
+	}
+	var getCtrl = newCtrlInfo()
+	getCtrl.Methods = []string{"GET"}
+	var root = newTree("/<name>")
+	root.Children[0].MethodCtrl = map[string]*controllerInfo{"GET": getCtrl}
+	root.Children[0].CtrlInfo = nil
+	if err := root.addRoute("/about", newCtrlInfo()); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.checkConflicts(); err != nil {
+		t.Error("test 6 failed", err)
+	}
+}
+
+func Test_routeTree_lookup(t *testing.T) {
+	var ctrl1 = newCtrlInfo()
+	var ctrl2 = newCtrlInfo()
+	var root = newRouteTree()
+	root.MatchCase = false
+	for _, p := range []string{"/About", "/edit-<user:word>", "/<id:int>.html", "/<action>/<id:int>"} {
+		if err := root.addRoute(p, ctrl1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := root.addRoute("/<name>/<id:int>", ctrl2); err != nil {
+		t.Fatal(err)
+	}
+	if c, rd, _ := root.lookup("/about", "get"); c != ctrl1 || rd != nil {
+		t.Error("test 1 failed")
+	}
+	if c, rd, _ := root.lookup("/Edit-steve", "get"); c != ctrl1 || rd["user"] != "steve" {
+		t.Error("test 2 failed")
+	}
+	if c, _, _ := root.lookup("/xedit-steve", "get"); c != nil {
+		t.Error("test 3 failed")
+	}
+	if c, _, _ := root.lookup("/5.htmlx", "get"); c != nil {
+		t.Error("test 4 failed")
+	}
+	if c, rd, _ := root.lookup("/index/1", "get"); c != ctrl1 || rd["action"] != "index" || rd["id"] != "1" {
+		t.Error("test 5 failed")
+	}
+	if c, rd, _ := root.lookup("/missing/1", "get"); c != ctrl2 || rd["name"] != "missing" || len(rd) != 2 {
+		t.Error("test 6 failed", rd)
+	}
+}
+
+func newBenchTree(b *testing.B) *routeTree {
+	var ctrlInfo = newCtrlInfo()
+	var root = newRouteTree()
+	var paths []string
+	for i := 0; i < 100; i++ {
+		var n = strconv.Itoa(i)
+		paths = append(paths,
+			"/api/v1/res"+n+"/<id:int>",
+			"/api/v1/res"+n+"/<id:int>/items/<item:word>",
+			"/page"+n+"/about-us")
+	}
+	paths = append(paths, "/assets/*pathInfo", "/blog/<year:int(4)>-<month:int(2)>/<slug:regex([a-z0-9-]+)>")
+	for _, p := range paths {
+		if err := root.addRoute(p, ctrlInfo); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return root
+}
+
+func benchLookup(b *testing.B, urlPath string, found bool) {
+	var root = newBenchTree(b)
+	if c, _, _ := root.lookup(urlPath, "get"); (c != nil) != found {
+		b.Fatal("unexpected lookup result", urlPath)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.lookup(urlPath, "get")
+	}
+}
+
+func Benchmark_routeTree_lookupStatic(b *testing.B) {
+	benchLookup(b, "/page99/about-us", true)
+}
+
+func Benchmark_routeTree_lookupParam(b *testing.B) {
+	benchLookup(b, "/api/v1/res99/12/items/box", true)
+}
+
+func Benchmark_routeTree_lookupPattern(b *testing.B) {
+	benchLookup(b, "/blog/2016-05/hello-world", true)
+}
+
+func Benchmark_routeTree_lookupCatchAll(b *testing.B) {
+	benchLookup(b, "/assets/css/site.css", true)
+}
+
+func Benchmark_routeTree_lookupNotFound(b *testing.B) {
+	benchLookup(b, "/api/v1/res99/abc", false)
+}
+
+func Test_routeNode_staticChild(t *testing.T) {
+	var root = newRouteTree()
+	for _, p := range []string{"/About", "/<name>", "/Ünit"} {
+		if err := root.addRoute(p, newCtrlInfo()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var l = getLookup("get")
+	defer putLookup(l)
+	if index := root.staticChild("ABOUT", false, l); index < 0 || root.Children[index].Path != "About" {
+		t.Error("test 1 failed")
+	}
+	if index := root.staticChild("ABOUT", true, l); index >= 0 {
+		t.Error("test 2 failed")
+	}
+	if index := root.staticChild("üNIT", false, l); index < 0 || root.Children[index].Path != "Ünit" {
+		t.Error("test 3 failed")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { root.staticChild("ABOUT", false, l) }); allocs != 0 {
+		t.Error("test 4 failed", allocs)
+	}
+}
+
+func Benchmark_routeTree_lookupFold(b *testing.B) {
+	var root = newBenchTree(b)
+	root.MatchCase = false
+	var urlPath = "/PAGE99/About-Us"
+	if c, _, _ := root.lookup(urlPath, "get"); c == nil {
+		b.Fatal("unexpected lookup result", urlPath)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.lookup(urlPath, "get")
+	}
+}
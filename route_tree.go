@@ -9,8 +9,9 @@ import (
 
 type routeTree struct {
 	routeNode
-	funcMap   map[string]RouteValidateFunc
-	MatchCase bool
+	funcMap        map[string]RouteValidateFunc
+	friendlyAction bool
+	MatchCase      bool
 }
 
 func (tree *routeTree) addFunc(name string, fun RouteValidateFunc) error {
@@ -27,196 +28,241 @@ func (tree *routeTree) addFunc(name string, fun RouteValidateFunc) error {
 	return nil
 }
 
-func (tree *routeTree) lookupDepth(indexNode *routeNode, pathLength uint16, urlParts []string, method string, endWithSlash bool) (found bool, ctrl *controllerInfo, routeMap map[string]string) {
-	found = false
-	ctrl = nil
-	routeMap = nil
-	if indexNode.MaxDepth+indexNode.CurDepth <= pathLength || indexNode.NodeType == rtRoot {
-		return
+// lookupDepth match the node and its children with the url path parts
+func (tree *routeTree) lookupDepth(node *routeNode, l *routeLookup) *controllerInfo {
+	if node.MaxDepth+node.CurDepth <= l.pathLength {
+		return nil
 	}
-	var routeData = make(map[string]string)
-	var curPath = urlParts[indexNode.CurDepth-1]
-	if indexNode.NodeType == rtCatchAll {
+	var mark = l.mark()
+	switch node.NodeType {
+	case rtCatchAll:
 		// deal with *pathInfo
-		var path string
-		for _, part := range urlParts[indexNode.CurDepth-1:] {
-			path = strAdd(path, "/", part)
-		}
-		if endWithSlash {
-			path = strAdd(path, "/")
+		var ctrl = l.ctrlFor(node)
+		if ctrl == nil {
+			return nil
 		}
-		routeData["pathInfo"] = strings.TrimLeft(path, "/")
-		found = true
-		ctrl = indexNode.CtrlInfo
-		routeMap = routeData
-		return
-	} else if indexNode.NodeType == rtStatic {
-		// deal with static path
-		str1 := indexNode.Path
-		str2 := curPath
-		if !tree.MatchCase {
-			str1 = strings.ToLower(str1)
-			str2 = strings.ToLower(str2)
+		l.push("pathInfo", l.pathInfo(node.CurDepth-1))
+		if !tree.checkAction(ctrl, l) {
+			l.reset(mark)
+			return nil
 		}
-		if str1 != str2 {
-			return
+		for depth := node.CurDepth - 1; depth < l.pathLength; depth++ {
+			l.fix(depth, l.urlParts[depth])
 		}
-	} else if indexNode.NodeType == rtParam {
+		return ctrl
+	case rtStatic:
+		// the static node is found by the path index of the parent node
+		l.fix(node.CurDepth-1, node.Path)
+	case rtParam:
 		// deal with dynamic path
-		var dynPathSplits []string // the dynamic route paths that to be check
-		var str1 string
-		var str2 string
+		if !tree.matchParams(node, l.urlParts[node.CurDepth-1], l) {
+			l.reset(mark)
+			return nil
+		}
+	default:
+		return nil
+	}
+	var ctrl *controllerInfo
+	if node.CurDepth == l.pathLength {
+		ctrl = tree.matchLeaf(node, l)
+	} else if node.hasChildren() {
+		ctrl = tree.lookupChildren(node, l)
+	}
+	if ctrl == nil {
+		l.reset(mark)
+	}
+	return ctrl
+}
 
-		var checkFunc = func(index int) {
-			if len(dynPathSplits) > 0 {
-				validationStr := curPath[0:index]
-				for _, dynPath := range dynPathSplits {
-					paramName := dynPath[1 : len(dynPath)-1]
-					opt := indexNode.Params[paramName]
-					if len(validationStr) == 0 {
-						if !opt.HasDefaultValue {
-							return
-						}
-						routeData[paramName] = opt.DefaultValue
-					} else {
-						validateFunc := tree.funcMap[opt.Validation]
-						if validateFunc == nil {
-							return
-						}
-						data := validateFunc(validationStr, opt)
-						if len(data) == 0 || len(data) > len(validationStr) {
-							return
-						}
-						routeData[paramName] = data
-						validationStr = validationStr[len(data):]
-					}
-				}
-				// check the last part of the route param
-				if len(curPath) == index && len(validationStr) > 0 {
-					return
-				}
+// lookupChildren match the children of the node in the order of the registration.
+// The static child is found by the index instead of comparing the path with all the static children
+func (tree *routeTree) lookupChildren(node *routeNode, l *routeLookup) *controllerInfo {
+	var staticIndex = node.staticChild(l.urlParts[node.CurDepth], tree.MatchCase, l)
+	for _, index := range node.dynIndex {
+		if staticIndex >= 0 && staticIndex < index {
+			if ctrl := tree.lookupDepth(node.Children[staticIndex], l); ctrl != nil {
+				return ctrl
 			}
-			dynPathSplits = nil
-			curPath = curPath[index+len(str1):]
+			staticIndex = -1
+		}
+		if ctrl := tree.lookupDepth(node.Children[index], l); ctrl != nil {
+			return ctrl
 		}
+	}
+	if staticIndex >= 0 {
+		return tree.lookupDepth(node.Children[staticIndex], l)
+	}
+	return nil
+}
 
-		for _, p := range indexNode.PathSplits {
-			if tree.isParamPath(p) {
-				dynPathSplits = append(dynPathSplits, p)
-				continue
-			}
-			str1 = p
-			str2 = curPath
-			if !tree.MatchCase {
-				str1 = strings.ToLower(str1)
-				str2 = strings.ToLower(str2)
+// matchParams match the url path part with the static and param parts of the node, the route params are pushed to the lookup state
+func (tree *routeTree) matchParams(node *routeNode, curPath string, l *routeLookup) bool {
+	var segment = curPath
+	var fixed string // the part of the segment that is fixed to the letter case of the route
+	var copied = 0   // the length of the segment that is copied to 'fixed'
+	var paramStart = -1
+	for i, p := range node.PathSplits {
+		if node.isParamPath(p) {
+			if paramStart < 0 {
+				paramStart = i
 			}
-			index := strings.Index(str2, str1)
-			if index == -1 {
-				return
+			continue
+		}
+		var index int
+		if tree.MatchCase {
+			index = strings.Index(curPath, p)
+		} else {
+			index = indexFold(curPath, p)
+		}
+		if index < 0 {
+			return false
+		}
+		if paramStart < 0 {
+			if index != 0 {
+				return false
 			}
-			checkFunc(index)
+		} else if !tree.matchParamValues(node, node.PathSplits[paramStart:i], curPath[:index], l) {
+			return false
 		}
-		str1 = ""
-		checkFunc(len(curPath))
-		if len(curPath) != 0 {
-			return
+		if start := len(segment) - len(curPath) + index; l.fixCase && segment[start:start+len(p)] != p {
+			fixed = strAdd(fixed, segment[copied:start], p)
+			copied = start + len(p)
 		}
-	} else {
-		return
+		paramStart = -1
+		curPath = curPath[index+len(p):]
 	}
-	if indexNode.CurDepth == pathLength {
-		ctrl = indexNode.CtrlInfo
-		routeMap = routeData
-		// detect default value
-		if ctrl == nil {
-			f, c, rm := indexNode.detectDefault(method)
-			if f {
-				found = true
-				ctrl = c
-				if rm != nil {
-					for key, value := range rm {
-						routeMap[key] = value
-					}
-				}
-			} else {
-				found = false
-				routeMap = nil
-				ctrl = nil
-			}
-		} else {
-			found = true
+	if paramStart >= 0 {
+		if !tree.matchParamValues(node, node.PathSplits[paramStart:], curPath, l) {
+			return false
 		}
-		if found {
-			a, ok := routeMap["action"]
-			if ok {
-				if action := ctrl.findActionName(a, method, app.friendlyActionName); len(action) == 0 {
-					return false, nil, nil
-				}
+	} else if len(curPath) != 0 {
+		return false
+	}
+	if copied > 0 {
+		segment = strAdd(fixed, segment[copied:])
+	}
+	l.fix(node.CurDepth-1, segment)
+	return true
+}
+
+// matchParamValues validate the value with the route params, the value should be consumed by the params
+func (tree *routeTree) matchParamValues(node *routeNode, params []string, value string, l *routeLookup) bool {
+	for _, p := range params {
+		var paramName = p[1 : len(p)-1]
+		var opt = node.Params[paramName]
+		if len(value) == 0 {
+			if !opt.HasDefaultValue {
+				return false
 			}
+			l.push(paramName, opt.DefaultValue)
+			continue
+		}
+		validateFunc := tree.funcMap[opt.Validation]
+		if validateFunc == nil {
+			return false
 		}
-		return
+		data := validateFunc(value, opt)
+		if len(data) == 0 || len(data) > len(value) {
+			return false
+		}
+		l.push(paramName, data)
+		value = value[len(data):]
 	}
-	// check the last url parts
-	if !indexNode.hasChildren() {
-		return
+	return len(value) == 0
+}
+
+// matchLeaf get the controller info of the node that matches the whole url path.
+// If the node has no controller info, the children with the default value are detected
+func (tree *routeTree) matchLeaf(node *routeNode, l *routeLookup) *controllerInfo {
+	if ctrl := l.ctrlFor(node); ctrl != nil {
+		if !tree.checkAction(ctrl, l) {
+			return nil
+		}
+		return ctrl
 	}
-	for _, child := range indexNode.Children {
-		ok, result, rd := tree.lookupDepth(child, pathLength, urlParts, method, endWithSlash)
-		if ok {
-			if rd != nil && len(rd) > 0 {
-				if _, ok = rd["pathInfo"]; ok {
-					if a, ok := rd["action"]; ok {
-						if action := result.findActionName(a, method, app.friendlyActionName); len(action) == 0 {
-							return false, nil, nil
-						}
-					}
-				}
-				for key, value := range rd {
-					routeData[key] = value
-				}
+	return tree.detectDefault(node, l)
+}
+
+// detectDefault detect the children that have only one route param with default value, like '<action=index>'
+func (tree *routeTree) detectDefault(node *routeNode, l *routeLookup) *controllerInfo {
+	for _, child := range node.Children {
+		if child.NodeType != rtParam || len(child.PathSplits) != 1 || !node.isParamPath(child.PathSplits[0]) {
+			continue
+		}
+		var paramName = child.PathSplits[0][1 : len(child.PathSplits[0])-1]
+		var opt = child.Params[paramName]
+		if opt == nil || !opt.HasDefaultValue {
+			continue
+		}
+		var mark = l.mark()
+		l.push(paramName, opt.DefaultValue)
+		if child.hasCtrl() {
+			if ctrl := l.ctrlFor(child); ctrl != nil && tree.checkAction(ctrl, l) {
+				return ctrl
 			}
-			found = true
-			ctrl = result
-			routeMap = routeData
-			return
+		} else if ctrl := tree.detectDefault(child, l); ctrl != nil {
+			return ctrl
 		}
+		l.reset(mark)
+	}
+	return nil
+}
+
+// checkAction check the 'action' route param is the action of the controller
+func (tree *routeTree) checkAction(ctrl *controllerInfo, l *routeLookup) bool {
+	action, ok := l.get("action")
+	if !ok {
+		return true
 	}
-	return
+	return len(ctrl.findActionName(action, l.method, tree.friendlyAction)) > 0
 }
 
 func (tree *routeTree) lookup(urlPath, method string) (*controllerInfo, map[string]string, error) {
+	m, err := tree.match(urlPath, method, false)
+	return m.ctrl, m.routeData, err
+}
+
+// match lookup the route of the url path and check the url path is canonical.
+// If fixCase is true and the route does not match case, the letter case of the clean path is fixed by the route
+func (tree *routeTree) match(urlPath, method string, fixCase bool) (routeMatch, error) {
+	var m routeMatch
 	if len(urlPath) == 0 {
 		urlPath = "/"
 	}
+	var l = getLookup(method)
+	defer putLookup(l)
 	if urlPath == "/" {
-		ctrl := tree.CtrlInfo
-		if ctrl == nil {
-			f, c, r := tree.detectDefault(method)
-			if f {
-				return c, r, nil
-			} else {
-				return nil, nil, nil
-			}
+		m.ctrl = tree.matchLeaf(&tree.routeNode, l)
+	} else {
+		var err error
+		if l.urlParts, err = appendURLParts(l.urlParts, urlPath); err != nil {
+			return m, err
 		}
-		return tree.CtrlInfo, nil, nil
-	}
-	urlParts, err := splitURLPath(urlPath)
-	if err != nil {
-		return nil, nil, err
+		l.pathLength = uint16(len(l.urlParts))
+		if l.pathLength == 0 || len(tree.Children) == 0 {
+			return m, nil
+		}
+		l.endWithSlash = strings.HasSuffix(urlPath, "/")
+		if fixCase && !tree.MatchCase {
+			l.fixCase = true
+			l.fixParts = append(l.fixParts, l.urlParts...)
+		}
+		m.ctrl = tree.lookupChildren(&tree.routeNode, l)
 	}
-	var pathLength = uint16(len(urlParts))
-	if pathLength == 0 || len(tree.Children) == 0 {
-		return nil, nil, nil
+	if m.ctrl == nil {
+		return m, newErrMethodNotAllowed(l.allowed)
 	}
-	var endWithSlash = strings.HasSuffix(urlPath, "/")
-	for _, child := range tree.Children {
-		ok, result, rd := tree.lookupDepth(child, pathLength, urlParts, method, endWithSlash)
-		if ok {
-			return result, rd, nil
+	m.routeData = l.routeData()
+	m.cleanPath = cleanURLPath(urlPath, l.urlParts)
+	m.slashDiff = m.cleanPath != urlPath
+	for i, p := range l.fixParts {
+		if p != l.urlParts[i] {
+			m.caseDiff = true
+			m.cleanPath = strAdd("/", strings.Join(l.fixParts, "/"))
+			break
 		}
 	}
-	return nil, nil, nil
+	return m, nil
 }
 
 func (tree *routeTree) addRoute(routePath string, ctrlInfo *controllerInfo) error {
@@ -227,10 +273,9 @@ func (tree *routeTree) addRoute(routePath string, ctrlInfo *controllerInfo) erro
 		return errors.New("'ctrlInfo' param cannot be nil")
 	}
 	if routePath == "/" {
-		if tree.CtrlInfo != nil {
+		if err := tree.addCtrl(ctrlInfo); err != nil {
 			return errors.New("Duplicate controller info for route '/'")
 		}
-		tree.CtrlInfo = ctrlInfo
 		return nil
 	}
 	branch, err := newRouteNode(routePath, ctrlInfo)
@@ -246,11 +291,18 @@ func (tree *routeTree) addRoute(routePath string, ctrlInfo *controllerInfo) erro
 func newRouteTree() *routeTree {
 	var node = &routeTree{
 		funcMap: map[string]RouteValidateFunc{
-			"int":    validateInt,
-			"any":    validateAny,
-			"word":   validateWord,
-			"enum":   validateEnum,
-			"action": validateActionName,
+			"int":      validateInt,
+			"any":      validateAny,
+			"word":     validateWord,
+			"enum":     validateEnum,
+			"action":   validateActionName,
+			"regex":    validateRegex,
+			"uuid":     validateUUID,
+			"date":     validateDate,
+			"hex":      validateHex,
+			"alpha":    validateAlpha,
+			"float":    validateFloat,
+			"intrange": validateIntRange,
 		},
 	}
 	node.NodeType = rtRoot
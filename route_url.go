@@ -0,0 +1,83 @@
+package wemvc
+
+import (
+	"net/url"
+	"strings"
+)
+
+// buildURL rebuild the URL from the route path with the route params.
+// The params that are not used by the route path are appended as query string
+func (tree *routeTree) buildURL(routePath string, params map[string]string) (string, error) {
+	if routePath == "/" {
+		return appendQuery("/", params, nil), nil
+	}
+	node, err := newRouteNode(routePath, nil)
+	if err != nil {
+		return "", err
+	}
+	var used = make(map[string]bool)
+	var buf []string
+	for ; node != nil; node = node.firstChild() {
+		switch node.NodeType {
+		case rtStatic:
+			buf = append(buf, url.PathEscape(node.Path))
+		case rtCatchAll:
+			pathInfo := params["pathInfo"]
+			used["pathInfo"] = true
+			var parts []string
+			for _, p := range strings.Split(strings.Trim(pathInfo, "/"), "/") {
+				if len(p) > 0 {
+					parts = append(parts, url.PathEscape(p))
+				}
+			}
+			buf = append(buf, strings.Join(parts, "/"))
+		case rtParam:
+			var segment string
+			for _, p := range node.PathSplits {
+				if !tree.isParamPath(p) {
+					segment = strAdd(segment, p)
+					continue
+				}
+				paramName := p[1 : len(p)-1]
+				opt := node.Params[paramName]
+				value, ok := params[paramName]
+				used[paramName] = true
+				if !ok || len(value) == 0 {
+					if !opt.HasDefaultValue {
+						return "", errRouteParamMissing(routePath, paramName)
+					}
+					value = opt.DefaultValue
+				} else {
+					validateFunc := tree.funcMap[opt.Validation]
+					if validateFunc == nil || validateFunc(value, opt) != value {
+						return "", errRouteParamInvalid(routePath, paramName, value)
+					}
+				}
+				segment = strAdd(segment, value)
+			}
+			buf = append(buf, url.PathEscape(segment))
+		}
+	}
+	return appendQuery(strAdd("/", strings.Join(buf, "/")), params, used), nil
+}
+
+func (node *routeNode) firstChild() *routeNode {
+	if !node.hasChildren() {
+		return nil
+	}
+	return node.Children[0]
+}
+
+func appendQuery(urlPath string, params map[string]string, used map[string]bool) string {
+	var query = url.Values{}
+	for key, value := range params {
+		if used[key] {
+			continue
+		}
+		query.Set(key, value)
+	}
+	if len(query) == 0 {
+		return urlPath
+	}
+	return strAdd(urlPath, "?", query.Encode())
+}
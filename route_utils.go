@@ -8,6 +8,18 @@ import (
 	"strings"
 )
 
+var (
+	paramNameReg, _   = regexp.Compile("^[a-zA-Z][\\w]*$")
+	paramOptionReg, _ = regexp.Compile("^[a-zA-Z][\\w]*\\(.*\\)$")
+	numberReg, _      = regexp.Compile("^[0-9]+$")
+	numberRangeReg, _ = regexp.Compile("^[0-9]+(~)+[0-9]+$")
+
+	httpMethods = []string{"GET", "POST", "HEAD", "PUT", "DELETE", "PATCH", "OPTIONS"}
+
+	// requiredSettings the validations that can not be used without the setting
+	requiredSettings = map[string]bool{"regex": true, "intrange": true}
+)
+
 func isA2Z(c byte) bool {
 	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
 }
@@ -24,19 +36,44 @@ func splitURLPath(urlPath string) ([]string, error) {
 	if len(urlPath) == 0 {
 		return nil, errors.New("The URL path is empty")
 	}
-	p := strings.Trim(urlPath, "/")
-	splits := strings.Split(p, "/")
-	var result []string
-	for _, s := range splits {
-		if len(s) == 0 || s == "." {
+	return appendURLParts(nil, urlPath)
+}
+
+// appendURLParts split the url path and append the parts to dst. The parts are the sub strings of the url path,
+// so no new string is allocated
+func appendURLParts(dst []string, urlPath string) ([]string, error) {
+	for len(urlPath) > 0 {
+		var part string
+		if index := strings.IndexByte(urlPath, '/'); index < 0 {
+			part, urlPath = urlPath, ""
+		} else {
+			part, urlPath = urlPath[:index], urlPath[index+1:]
+		}
+		if len(part) == 0 || part == "." {
 			continue
 		}
-		if s == ".." {
-			return nil, errors.New("Invalid URL path. The URL path cannot contains '..'")
+		if part == ".." {
+			return dst, errInvalidURLPath
+		}
+		dst = append(dst, part)
+	}
+	return dst, nil
+}
+
+// hasPrefixFold check the string s begins with prefix, the strings are compared case-insensitively
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// indexFold get the index of the first instance of substr in s, the strings are compared case-insensitively
+func indexFold(s, substr string) int {
+	var n = len(substr)
+	for i := 0; i+n <= len(s); i++ {
+		if strings.EqualFold(s[i:i+n], substr) {
+			return i
 		}
-		result = append(result, s)
 	}
-	return result, nil
+	return -1
 }
 
 func detectNodeType(p string) pathType {
@@ -127,23 +164,47 @@ func splitRouteParam(path string) []string {
 }
 
 func checkParamName(name string) bool {
-	reg, _ := regexp.Compile("^[a-zA-Z][\\w]*$")
-	return reg.Match(str2Byte(name))
+	return paramNameReg.MatchString(name)
 }
 
 func checkParamOption(optionStr string) bool {
-	reg, _ := regexp.Compile("^[a-zA-Z][\\w]*\\(.+\\)$")
-	return reg.Match(str2Byte(optionStr))
+	return paramOptionReg.MatchString(optionStr)
 }
 
 func checkNumber(opt string) bool {
-	reg, _ := regexp.Compile("^[0-9]+$")
-	return reg.Match(str2Byte(opt))
+	return numberReg.MatchString(opt)
 }
 
 func checkNumberRange(optStr string) bool {
-	reg, _ := regexp.Compile("^[0-9]+(~)+[0-9]+$")
-	return reg.Match(str2Byte(optStr))
+	return numberRangeReg.MatchString(optStr)
+}
+
+// parseIntRange parse the integer range setting like '1~1000' or '-10~10'
+func parseIntRange(setting string) (int64, int64, bool) {
+	index := strings.Index(setting[1:], "~")
+	if index < 0 {
+		return 0, 0, false
+	}
+	min, err := strconv.ParseInt(setting[:index+1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	max, err := strconv.ParseInt(setting[index+2:], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if min > max {
+		min, max = max, min
+	}
+	return min, max, true
+}
+
+// regexOption get the regular expression pattern from the route param option like 'regex([a-z0-9-]+)'
+func regexOption(optionStr string) (string, bool) {
+	if !strings.HasPrefix(optionStr, "regex(") || !strings.HasSuffix(optionStr, ")") {
+		return "", false
+	}
+	return optionStr[len("regex(") : len(optionStr)-1], true
 }
 
 func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error) {
@@ -153,7 +214,7 @@ func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error)
 	for _, sp := range splitParams {
 		if strings.HasSuffix(sp, rtParamEndStr) && strings.HasPrefix(sp, rtParamBeginStr) {
 			paramStr := strings.Trim(sp, rtParamBeginStr+rtParamEndStr)
-			splits := strings.Split(paramStr, ":")
+			splits := strings.SplitN(paramStr, ":", 2)
 			// paramName: the name of the route param (with default value), like 'name', 'name=Steve Jobs' or 'name='
 			paramName := splits[0]
 			// paramOptionStr: the route param option
@@ -173,9 +234,9 @@ func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error)
 					} else {
 						paramOptionStr = "any"
 					}
+				} else if _, isRegex := regexOption(paramOptionStr); !isRegex && strings.Contains(paramOptionStr, ":") {
+					return nil, nil, errors.New(strAdd("Invalid route parameter setting: ", sp))
 				}
-			} else if len(splits) > 2 {
-				return nil, nil, errors.New(strAdd("Invalid route parameter setting: ", sp))
 			}
 			opt := RouteOption{}
 			var eqIndex = strings.Index(paramName, "=")
@@ -189,7 +250,26 @@ func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error)
 			} else {
 				opt.HasDefaultValue = false
 			}
-			if checkParamName(paramOptionStr) {
+			if pattern, isRegex := regexOption(paramOptionStr); isRegex {
+				if len(pattern) == 0 {
+					return nil, nil, errors.New(strAdd("The setting is required by the route parameter: ", sp))
+				}
+				// the regular expression is anchored to the beginning of the route param, and the longest match is used so
+				// the alternations like 'a|ab' match the whole segment
+				reg, err := regexp.Compile(strAdd("^(?:", pattern, ")"))
+				if err != nil {
+					return nil, nil, errors.New(strAdd("Invalid regular expression in route parameter setting: ", sp, ". ", err.Error()))
+				}
+				reg.Longest()
+				opt.Validation = "regex"
+				opt.Setting = pattern
+				opt.Regexp = reg
+				opt.MaxLength = 255
+				opt.MinLength = 1
+			} else if checkParamName(paramOptionStr) {
+				if requiredSettings[paramOptionStr] {
+					return nil, nil, errors.New(strAdd("The setting is required by the route parameter: ", sp))
+				}
 				opt.Validation = paramOptionStr
 				opt.MaxLength = 255
 				opt.MinLength = 1
@@ -203,7 +283,22 @@ func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error)
 				if strings.Contains(setting, ")") {
 					return nil, nil, errors.New(strAdd("Invalid route parameter setting: ", sp))
 				}
-				if checkNumber(setting) {
+				if len(strings.TrimSpace(setting)) == 0 {
+					if requiredSettings[opt.Validation] {
+						return nil, nil, errors.New(strAdd("The setting is required by the route parameter: ", sp))
+					}
+					opt.MaxLength = 255
+					opt.MinLength = 1
+				} else if opt.Validation == "intrange" {
+					min, max, ok := parseIntRange(setting)
+					if !ok {
+						return nil, nil, errors.New(strAdd("Invalid route parameter setting: ", sp))
+					}
+					opt.MinValue = min
+					opt.MaxValue = max
+					opt.MaxLength = 255
+					opt.MinLength = 1
+				} else if checkNumber(setting) {
 					i, err := strconv.ParseUint(setting, 10, 0)
 					if err != nil {
 						return nil, nil, err
@@ -241,3 +336,13 @@ func analyzeParamOption(path string) ([]string, map[string]*RouteOption, error)
 	}
 	return paramPath, optionMap, nil
 }
+
+// upperMethod get the upper case http method. The common methods are returned without allocating new string
+func upperMethod(method string) string {
+	for _, m := range httpMethods {
+		if strings.EqualFold(method, m) {
+			return m
+		}
+	}
+	return strings.ToUpper(method)
+}
@@ -2,12 +2,16 @@ package wemvc
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
-	acReg, _   = regexp.Compile("^[a-zA-Z0-9_]+(-[a-zA-Z0-9_]+)*")
-	wordReg, _ = regexp.Compile("^[\\w]+")
+	acReg, _    = regexp.Compile("^[a-zA-Z0-9_]+(-[a-zA-Z0-9_]+)*")
+	wordReg, _  = regexp.Compile("^[\\w]+")
+	floatReg, _ = regexp.Compile("^[-+]?([0-9]+(\\.[0-9]+)?|\\.[0-9]+)([eE][-+]?[0-9]+)?")
+	intReg, _   = regexp.Compile("^-?[0-9]+")
 )
 
 // RouteFunc define the route check function
@@ -22,19 +26,15 @@ func validateAny(urlPath string, opt *RouteOption) string {
 }
 
 func validateInt(urlPath string, opt *RouteOption) string {
-	var numBytes []byte
-	for _, char := range str2Byte(urlPath) {
-		if isNumber(char) {
-			numBytes = append(numBytes, char)
-		} else {
-			break
-		}
-		if uint8(len(numBytes)) >= opt.MaxLength {
+	var length = 0
+	for length < len(urlPath) && isNumber(urlPath[length]) {
+		length++
+		if uint8(length) >= opt.MaxLength {
 			break
 		}
 	}
-	if uint8(len(numBytes)) >= opt.MinLength {
-		return byte2Str(numBytes)
+	if length > 0 && uint8(length) >= opt.MinLength {
+		return urlPath[:length]
 	}
 	return ""
 }
@@ -51,8 +51,14 @@ func validateEnum(urlPath string, opt *RouteOption) string {
 	if len(opt.Setting) == 0 {
 		return ""
 	}
-	var splits = strings.Split(opt.Setting, "|")
-	for _, value := range splits {
+	var setting = opt.Setting
+	for len(setting) > 0 {
+		var value string
+		if index := strings.IndexByte(setting, '|'); index < 0 {
+			value, setting = setting, ""
+		} else {
+			value, setting = setting[:index], setting[index+1:]
+		}
 		if strings.HasPrefix(urlPath, value) {
 			return value
 		}
@@ -67,3 +73,90 @@ func validateActionName(urlPath string, opt *RouteOption) string {
 	}
 	return byte2Str(bytes)
 }
+
+func validateRegex(urlPath string, opt *RouteOption) string {
+	if opt.Regexp == nil {
+		return ""
+	}
+	match := opt.Regexp.FindString(urlPath)
+	if len(match) > int(opt.MaxLength) || len(match) < int(opt.MinLength) {
+		return ""
+	}
+	return match
+}
+
+func validateUUID(urlPath string, opt *RouteOption) string {
+	// {xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx}, xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx or xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx
+	for _, length := range []int{38, 36, 32} {
+		if len(urlPath) < length {
+			continue
+		}
+		if _, err := ParseUUID(urlPath[:length]); err == nil {
+			return urlPath[:length]
+		}
+	}
+	return ""
+}
+
+func validateDate(urlPath string, opt *RouteOption) string {
+	// the ISO 8601 date like '2006-01-02'
+	if len(urlPath) < 10 {
+		return ""
+	}
+	if _, err := time.Parse("2006-01-02", urlPath[:10]); err != nil {
+		return ""
+	}
+	return urlPath[:10]
+}
+
+func validateHex(urlPath string, opt *RouteOption) string {
+	var length uint8
+	for i := 0; i < len(urlPath) && length < opt.MaxLength; i++ {
+		c := urlPath[i]
+		if !isNumber(c) && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			break
+		}
+		length++
+	}
+	if length >= opt.MinLength {
+		return urlPath[:length]
+	}
+	return ""
+}
+
+func validateAlpha(urlPath string, opt *RouteOption) string {
+	var length uint8
+	for i := 0; i < len(urlPath) && length < opt.MaxLength; i++ {
+		if !isA2Z(urlPath[i]) {
+			break
+		}
+		length++
+	}
+	if length >= opt.MinLength {
+		return urlPath[:length]
+	}
+	return ""
+}
+
+func validateFloat(urlPath string, opt *RouteOption) string {
+	match := floatReg.FindString(urlPath)
+	if len(match) > int(opt.MaxLength) || len(match) < int(opt.MinLength) {
+		return ""
+	}
+	if _, err := strconv.ParseFloat(match, 64); err != nil {
+		return ""
+	}
+	return match
+}
+
+func validateIntRange(urlPath string, opt *RouteOption) string {
+	match := intReg.FindString(urlPath)
+	if len(match) == 0 {
+		return ""
+	}
+	i, err := strconv.ParseInt(match, 10, 64)
+	if err != nil || i < opt.MinValue || i > opt.MaxValue {
+		return ""
+	}
+	return match
+}
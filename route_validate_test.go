@@ -0,0 +1,166 @@
+package wemvc
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestOption(t *testing.T, param string) *RouteOption {
+	_, opts, err := analyzeParamOption(param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range opts {
+		return opt
+	}
+	t.Fatal("no route option found: ", param)
+	return nil
+}
+
+func Test_validateUUID(t *testing.T) {
+	opt := newTestOption(t, "<id:uuid>")
+	if validateUUID("6ba7b810-9dad-11d1-80b4-00c04fd430c8", opt) != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Error("test 1 failed")
+	}
+	if validateUUID("6ba7b8109dad11d180b400c04fd430c8.json", opt) != "6ba7b8109dad11d180b400c04fd430c8" {
+		t.Error("test 2 failed")
+	}
+	if validateUUID("{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}", opt) != "{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}" {
+		t.Error("test 3 failed")
+	}
+	if validateUUID("6ba7b810-9dad-11d1-80b4", opt) != "" {
+		t.Error("test 4 failed")
+	}
+	if validateUUID("zba7b8109dad11d180b400c04fd430c8", opt) != "" {
+		t.Error("test 5 failed")
+	}
+}
+
+func Test_validateDate(t *testing.T) {
+	opt := newTestOption(t, "<day:date>")
+	if validateDate("2016-02-29", opt) != "2016-02-29" {
+		t.Error("test 1 failed")
+	}
+	if validateDate("2016-02-29-report", opt) != "2016-02-29" {
+		t.Error("test 2 failed")
+	}
+	if validateDate("2015-02-29", opt) != "" {
+		t.Error("test 3 failed")
+	}
+	if validateDate("2016-2-1", opt) != "" {
+		t.Error("test 4 failed")
+	}
+}
+
+func Test_validateHex(t *testing.T) {
+	if validateHex("5f3aBCz", newTestOption(t, "<id:hex>")) != "5f3aBC" {
+		t.Error("test 1 failed")
+	}
+	if validateHex("5f3aBC", newTestOption(t, "<id:hex(4)>")) != "5f3a" {
+		t.Error("test 2 failed")
+	}
+	if validateHex("5f3", newTestOption(t, "<id:hex(4)>")) != "" {
+		t.Error("test 3 failed")
+	}
+	if validateHex("xyz", newTestOption(t, "<id:hex>")) != "" {
+		t.Error("test 4 failed")
+	}
+}
+
+func Test_validateAlpha(t *testing.T) {
+	if validateAlpha("abcXYZ123", newTestOption(t, "<name:alpha>")) != "abcXYZ" {
+		t.Error("test 1 failed")
+	}
+	if validateAlpha("ab", newTestOption(t, "<name:alpha(3~5)>")) != "" {
+		t.Error("test 2 failed")
+	}
+	if validateAlpha("_abc", newTestOption(t, "<name:alpha>")) != "" {
+		t.Error("test 3 failed")
+	}
+}
+
+func Test_validateFloat(t *testing.T) {
+	opt := newTestOption(t, "<price:float>")
+	if validateFloat("12.50", opt) != "12.50" {
+		t.Error("test 1 failed")
+	}
+	if validateFloat("-3.2e5x", opt) != "-3.2e5" {
+		t.Error("test 2 failed")
+	}
+	if validateFloat(".5", opt) != ".5" {
+		t.Error("test 3 failed")
+	}
+	if validateFloat("abc", opt) != "" {
+		t.Error("test 4 failed")
+	}
+	if validateFloat(strings.Repeat("1", 257), opt) != "" {
+		t.Error("test 5 failed")
+	}
+}
+
+func Test_validateIntRange(t *testing.T) {
+	opt := newTestOption(t, "<id:intrange(1~1000)>")
+	if opt.MinValue != 1 || opt.MaxValue != 1000 {
+		t.Fatal("test 1 failed")
+	}
+	if validateIntRange("1000", opt) != "1000" {
+		t.Error("test 2 failed")
+	}
+	if validateIntRange("1001", opt) != "" {
+		t.Error("test 3 failed")
+	}
+	if validateIntRange("0", opt) != "" {
+		t.Error("test 4 failed")
+	}
+	opt = newTestOption(t, "<offset:intrange(10~-10)>")
+	if opt.MinValue != -10 || opt.MaxValue != 10 || validateIntRange("-5", opt) != "-5" {
+		t.Error("test 5 failed")
+	}
+	if _, _, err := analyzeParamOption("<id:intrange>"); err == nil {
+		t.Error("test 6 failed")
+	}
+	if _, _, err := analyzeParamOption("<id:intrange(a~b)>"); err == nil {
+		t.Error("test 7 failed")
+	}
+	for _, param := range []string{"<id:intrange()>", "<id:intrange( )>"} {
+		_, _, err := analyzeParamOption(param)
+		if err == nil || !strings.Contains(err.Error(), "The setting is required") {
+			t.Error("test 8 failed", param, err)
+		}
+	}
+	if opt = newTestOption(t, "<id:int()>"); opt.Validation != "int" || opt.MaxLength != 255 || opt.MinLength != 1 {
+		t.Error("test 9 failed", opt)
+	}
+}
+
+func Test_routeTree_typedParams(t *testing.T) {
+	var ctrlInfo = newCtrlInfo()
+	var root = newRouteTree()
+	if err := root.addRoute("/archive/<day:date>/<page:intrange(1~50)>", ctrlInfo); err != nil {
+		t.Fatal(err)
+	}
+	if c, rd, _ := root.lookup("/archive/2016-05-01/3", "get"); c == nil || rd["day"] != "2016-05-01" || rd["page"] != "3" {
+		t.Error("test 1 failed")
+	}
+	if c, _, _ := root.lookup("/archive/2016-05-01/51", "get"); c != nil {
+		t.Error("test 2 failed")
+	}
+}
+
+func Test_validateRegex(t *testing.T) {
+	opt := newTestOption(t, "<v:regex(a|ab)>")
+	if validateRegex("ab", opt) != "ab" || validateRegex("a", opt) != "a" {
+		t.Error("test 1 failed")
+	}
+	opt = newTestOption(t, "<v:regex(\\d+|\\d+-\\d+)>")
+	if validateRegex("12-34/edit", opt) != "12-34" || validateRegex("12", opt) != "12" {
+		t.Error("test 2 failed")
+	}
+	var root = newRouteTree()
+	if err := root.addRoute("/x/<v:regex(a|ab)>", newCtrlInfo()); err != nil {
+		t.Fatal(err)
+	}
+	if c, rd, _ := root.lookup("/x/ab", "get"); c == nil || rd["v"] != "ab" {
+		t.Error("test 3 failed")
+	}
+}
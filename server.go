@@ -1,48 +1,62 @@
 package wemvc
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
-	"encoding/json"
-	"encoding/xml"
-
 	"container/list"
 	"net/url"
 	"runtime"
+	"sync"
 	"time"
 )
 
 type EventHandler func() error
 
-type server struct {
-	errorHandlers      map[int]ErrorHandler
-	domain             string
-	port               int
-	webRoot            string
-	config             *config
-	routing            *routeTree
-	locked             bool
-	friendlyActionName bool
-	staticPaths        []string
-	staticFiles        []string
-	globalSession      *SessionManager
-	namespaces         map[string]*NsSection
-	sessionProvides    map[string]SessionProvider
-	internalErr        error
-	fileWatcher        *FileWatcher
-	cacheManager       *CacheManager
-	routeRules         []*routeConfig
-	appInitEvents      []EventHandler
-	httpReqEvents      map[requestEvent][]CtxFilter
+// Application the web application. It holds the routing, namespaces, views, sessions,
+// cache and file watcher of one web site, so that several applications can run in one process.
+type Application struct {
+	errorHandlers   map[int]ErrorHandler
+	domain          string
+	port            int
+	webRoot         string
+	config          *config
+	routing         *routeTree
+	slashPolicy     URLPolicy
+	casePolicy      URLPolicy
+	locked          bool
+	staticPaths     []string
+	staticFiles     []string
+	globalSession   *SessionManager
+	namespaces      map[string]*NsSection
+	sessionProvides map[string]SessionProvider
+	formatters      []*mediaFormatter
+	sessionCodecs   map[string]SessionCodec
+	compression     *compressOptions
+	autoETag        bool
+	internalErr     error
+	fileWatcher     *FileWatcher
+	cacheManager    *CacheManager
+	routeRules      []*routeConfig
+	namedRoutes     map[string]*routeConfig
+	appInitEvents   []EventHandler
+	appShutEvents   []EventHandler
+	httpReqEvents   map[requestEvent][]CtxFilter
+	httpServer      *http.Server
+	initOnce        sync.Once
+	initErr         error
+	shutdownLock    sync.Mutex
+	isShutdown      bool
 	viewContainer
 	filterContainer
 }
 
-func (app *server) onAppInit(h EventHandler) {
+func (app *Application) onAppInit(h EventHandler) {
 	app.assertNotLocked()
 	if h == nil {
 		return
@@ -50,13 +64,21 @@ func (app *server) onAppInit(h EventHandler) {
 	app.appInitEvents = append(app.appInitEvents, h)
 }
 
+func (app *Application) onAppShutdown(h EventHandler) {
+	app.assertNotLocked()
+	if h == nil {
+		return
+	}
+	app.appShutEvents = append(app.appShutEvents, h)
+}
+
 // MapPath Returns the physical file path that corresponds to the specified virtual path.
-func (app *server) mapPath(virtualPath string) string {
+func (app *Application) mapPath(virtualPath string) string {
 	var res = path.Join(app.webRoot, virtualPath)
 	return fixPath(res)
 }
 
-func (app *server) regSessionProvider(name string, provider SessionProvider) {
+func (app *Application) regSessionProvider(name string, provider SessionProvider) {
 	app.assertNotLocked()
 	if provider == nil {
 		panic(errSessionProvNil)
@@ -68,7 +90,12 @@ func (app *server) regSessionProvider(name string, provider SessionProvider) {
 }
 
 // ServeHTTP serve the
-func (app *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+func (app *Application) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// compress the response
+	if cw := app.compressWriter(w, req); cw != nil {
+		defer cw.Close()
+		w = cw
+	}
 	// handle 500 errors
 	defer app.panicRecover(w, req)
 	if app.internalErr != nil {
@@ -80,9 +107,11 @@ func (app *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		w:   w,
 		app: app,
 	}
+	defer ctx.unlockSession()
 	app.execReqEvents(beforeCheck, ctx)
 	app.execReqEvents(afterCheck, ctx)
-	if app.isStaticRequest(req) {
+	if staticURL, ok := app.staticURL(req.URL.Path); ok {
+		ctx.staticURL = staticURL
 		app.execReqEvents(beforeStatic, ctx)
 		app.execReqEvents(afterStatic, ctx)
 	} else {
@@ -94,11 +123,13 @@ func (app *server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		app.execReqEvents(beforeAction, ctx)
 		app.execReqEvents(afterAction, ctx)
 	}
+	// save the session before the headers are written
+	ctx.releaseSession()
 	// flush the request
 	app.flushRequest(w, req, ctx.Result)
 }
 
-func (app *server) staticDir(pathPrefix string) {
+func (app *Application) staticDir(pathPrefix string) {
 	app.assertNotLocked()
 	if len(pathPrefix) < 1 {
 		panic(errPathPrefix)
@@ -109,13 +140,10 @@ func (app *server) staticDir(pathPrefix string) {
 	if !strings.HasSuffix(pathPrefix, "/") {
 		pathPrefix = strAdd(pathPrefix, "/")
 	}
-	if runtime.GOOS == "windows" {
-		pathPrefix = strings.ToLower(pathPrefix)
-	}
 	app.staticPaths = append(app.staticPaths, pathPrefix)
 }
 
-func (app *server) staticFile(path string) {
+func (app *Application) staticFile(path string) {
 	app.assertNotLocked()
 	if len(path) < 1 {
 		panic(errPathPrefix)
@@ -126,13 +154,10 @@ func (app *server) staticFile(path string) {
 	if strings.HasSuffix(path, "/") {
 		panic(errInvalidPath)
 	}
-	if runtime.GOOS == "windows" {
-		path = strings.ToLower(path)
-	}
 	app.staticFiles = append(app.staticFiles, path)
 }
 
-func (app *server) getNamespace(nsName string) *NsSection {
+func (app *Application) getNamespace(nsName string) *NsSection {
 	if len(nsName) > 0 {
 		if !strings.HasPrefix(nsName, "/") {
 			nsName = strAdd("/", nsName)
@@ -158,23 +183,47 @@ func (app *server) getNamespace(nsName string) *NsSection {
 	return ns
 }
 
-func (app *server) assertNotLocked() {
+func (app *Application) assertNotLocked() {
 	if app.locked {
 		panic("Invalid operation. You cannot call this function after the server setting is locked")
 	}
 }
 
-func (app *server) addRoute(namespace string, routePath string, c interface{}, action string) {
-	app.routeRules = append(app.routeRules, &routeConfig{
+func (app *Application) addRoute(name, namespace, routePath string, c interface{}, action string, methods []string) {
+	var routeMethods []string
+	for _, m := range methods {
+		if len(m) == 0 || strings.ContainsAny(m, " \t/") {
+			panic(errInvalidMethod(m))
+		}
+		routeMethods = append(routeMethods, strings.ToUpper(m))
+	}
+	rule := &routeConfig{
 		namespace: namespace,
-		name : "",
+		name:      name,
 		routePath: routePath,
-		c: c,
-		action: action,
-	})
+		c:         c,
+		action:    action,
+		methods:   routeMethods,
+	}
+	if len(name) > 0 {
+		if _, ok := app.namedRoutes[name]; ok {
+			panic(errRouteNameTwice(name))
+		}
+		app.namedRoutes[name] = rule
+	}
+	app.routeRules = append(app.routeRules, rule)
 }
 
-func (app *server) flushRequest(w http.ResponseWriter, req *http.Request, result interface{}) {
+// urlFor generate the URL of the named route
+func (app *Application) urlFor(name string, params map[string]string) (string, error) {
+	rule, ok := app.namedRoutes[name]
+	if !ok {
+		return "", errRouteNotFound(name)
+	}
+	return app.routing.buildURL(rule.routePath, params)
+}
+
+func (app *Application) flushRequest(w http.ResponseWriter, req *http.Request, result interface{}) {
 	if result == nil {
 		result = app.handleErrorReq(req, 404)
 	}
@@ -200,27 +249,12 @@ func (app *server) flushRequest(w http.ResponseWriter, req *http.Request, result
 		w.Write([]byte{result.(byte)})
 		return
 	default:
-		var cType = req.Header.Get("Content-Type")
-		var contentBytes []byte
-		var err error
-		if cType == "text/xml" {
-			contentBytes, err = xml.Marshal(result)
-			if err != nil {
-				panic(err)
-			}
-			w.Header().Add("Content-Type", "text/xml")
-		} else {
-			contentBytes, err = json.Marshal(result)
-			if err != nil {
-				panic(err)
-			}
-			w.Header().Add("Content-Type", "application/json")
-		}
-		w.Write(contentBytes)
+		// the implicit result is formatted by the formatter that the request accepts, JSON is used if any type is accepted
+		(&NegotiateResult{Data: result, app: app}).ExecResult(w, req)
 	}
 }
 
-func (app *server) initWatcher() error {
+func (app *Application) initWatcher() error {
 	// add config file handler
 	app.fileWatcher.AddHandler(&fsConfigHandler{app: app})
 	// add ns config handler
@@ -234,7 +268,7 @@ func (app *server) initWatcher() error {
 	return nil
 }
 
-func (app *server) initConfig() error {
+func (app *Application) initConfig() error {
 	// load & watch the global config files
 	globalConfigFile := app.mapPath("/config.xml")
 	conf, err := newConfig(globalConfigFile)
@@ -251,6 +285,7 @@ func (app *server) initConfig() error {
 				EnableSetCookie: true,
 				SessionIDLength: 32,
 			},
+			ServerConfig: &ServerConfig{},
 		}
 	} else {
 		err1 := app.fileWatcher.AddWatch(globalConfigFile)
@@ -259,21 +294,75 @@ func (app *server) initConfig() error {
 		}
 	}
 	app.config = conf
+	if err := app.initURLPolicy(); err != nil {
+		return err
+	}
+	if conf.ServerConfig != nil && conf.ServerConfig.ETag {
+		app.autoETag = true
+	}
+	return app.initCompression()
+}
+
+// initURLPolicy set the url policies by the server config. The policy settings in config file take precedence over the code
+func (app *Application) initURLPolicy() error {
+	var conf = app.config.ServerConfig
+	if conf == nil {
+		return nil
+	}
+	if len(conf.TrailingSlash) > 0 {
+		p, err := parseURLPolicy(conf.TrailingSlash)
+		if err != nil {
+			return err
+		}
+		app.slashPolicy = p
+	}
+	if len(conf.LetterCase) > 0 {
+		p, err := parseURLPolicy(conf.LetterCase)
+		if err != nil {
+			return err
+		}
+		app.setCasePolicy(p)
+	}
 	return nil
 }
 
-func (app *server) initRoute() error {
+// setCasePolicy set the letter case policy. The route matches case only if the policy is strict
+func (app *Application) setCasePolicy(p URLPolicy) {
+	if !p.valid() {
+		panic(errInvalidURLPolicy(p.String()))
+	}
+	app.casePolicy = p
+	app.routing.MatchCase = p == PolicyStrict
+}
+
+func (app *Application) initRoute() error {
 	if len(app.routeRules) > 0 {
 		for _, rule := range app.routeRules {
-			cInfo := rule.genCtrlInfo(app.friendlyActionName)
-			app.routing.addRoute(rule.routePath, cInfo)
+			cInfo := rule.genCtrlInfo(app.routing.friendlyAction)
+			if err := app.routing.addRoute(rule.routePath, cInfo); err != nil {
+				return fmt.Errorf("Invalid route '%s': %s", rule.routePath, err.Error())
+			}
 		}
 	}
-	return nil
+	return app.routing.checkConflicts()
 }
 
-func (app *server) initViews() error {
-	app.addViewFunc("include", include_view)
+// routeTable get the route table of the route rules
+func (app *Application) routeTable() (RouteTable, error) {
+	var table = make(RouteTable, 0, len(app.routeRules))
+	for _, rule := range app.routeRules {
+		info, err := rule.routeInfo(app.routing.friendlyAction)
+		if err != nil {
+			return nil, err
+		}
+		table = append(table, info)
+	}
+	return table, nil
+}
+
+func (app *Application) initViews() error {
+	app.addViewFunc("include", app.includeView)
+	app.addViewFunc("url", app.urlView)
 	app.addViewFunc("req_query", req_query)
 	app.addViewFunc("req_form", req_form)
 	app.addViewFunc("req_header", req_header)
@@ -281,6 +370,8 @@ func (app *server) initViews() error {
 	app.addViewFunc("req_host", req_host)
 	app.addViewFunc("cache", cache_view)
 	app.addViewFunc("session", session_view)
+	app.addViewFunc("field_error", field_error_view)
+	app.addViewFunc("field_errors", field_errors_view)
 	// build the view template and watch the changes
 	viewDir := app.viewFolder()
 	if IsDir(viewDir) {
@@ -298,7 +389,7 @@ func (app *server) initViews() error {
 	return nil
 }
 
-func (app *server) initNs() error {
+func (app *Application) initNs() error {
 	// process namespaces: build the views files and load the config
 	if app.namespaces != nil {
 		for _, ns := range app.namespaces {
@@ -327,9 +418,18 @@ func (app *server) initNs() error {
 	return nil
 }
 
-func (app *server) initSessionMgr() error {
+func (app *Application) initSessionMgr() error {
 	// init sessionManager
 	app.regSessionProvider("memory", &memSessionProvider{list: list.New(), sessions: make(map[string]*list.Element)})
+	if _, ok := app.sessionProvides["file"]; !ok {
+		app.regSessionProvider("file", &fileSessionProvider{})
+	}
+	if _, ok := app.sessionProvides["cookie"]; !ok {
+		app.regSessionProvider("cookie", &cookieSessionProvider{})
+	}
+	if _, ok := app.sessionProvides["sql"]; !ok {
+		app.regSessionProvider("sql", &sqlSessionProvider{conf: app.config})
+	}
 	mgr, err := app.NewSessionManager(app.config.SessionConfig.ManagerName, app.config.SessionConfig)
 	if err != nil {
 		return err
@@ -339,32 +439,121 @@ func (app *server) initSessionMgr() error {
 	return nil
 }
 
-func (app *server) initCacheMgr() error {
+func (app *Application) initCacheMgr() error {
 	// init cache manager
 	app.cacheManager = newCacheManager(app.fileWatcher, 10*time.Second)
 	app.cacheManager.start()
 	return nil
 }
 
-func (app *server) initErrorHandler() error {
+func (app *Application) closeWatcher() error {
+	if app.fileWatcher == nil {
+		return nil
+	}
+	return app.fileWatcher.Close()
+}
+
+func (app *Application) closeSessionMgr() error {
+	if app.globalSession != nil {
+		app.globalSession.StopGC()
+	}
+	return nil
+}
+
+func (app *Application) closeCacheMgr() error {
+	if app.cacheManager != nil {
+		app.cacheManager.stop()
+	}
+	return nil
+}
+
+func (app *Application) initErrorHandler() error {
 	app.errorHandlers[404] = app.error404
 	app.errorHandlers[403] = app.error403
 	return nil
 }
 
-func (app *server) init() error {
-	// init the error handler
-	var err error
-	for _, h := range app.appInitEvents {
-		err = h()
-		if err != nil {
-			return err
+func (app *Application) init() error {
+	app.initOnce.Do(func() {
+		for _, h := range app.appInitEvents {
+			if app.initErr = h(); app.initErr != nil {
+				return
+			}
 		}
+		app.locked = true
+	})
+	return app.initErr
+}
+
+func (app *Application) newHTTPServer(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: app}
+	if conf := app.config.ServerConfig; conf != nil {
+		srv.ReadTimeout = time.Duration(conf.ReadTimeout) * time.Second
+		srv.ReadHeaderTimeout = time.Duration(conf.ReadHeaderTimeout) * time.Second
+		srv.WriteTimeout = time.Duration(conf.WriteTimeout) * time.Second
+		srv.IdleTimeout = time.Duration(conf.IdleTimeout) * time.Second
+	}
+	return srv
+}
+
+func (app *Application) serve(ctx context.Context, srv *http.Server, listen func() error) error {
+	app.shutdownLock.Lock()
+	if app.isShutdown {
+		app.shutdownLock.Unlock()
+		return http.ErrServerClosed
+	}
+	app.httpServer = srv
+	app.shutdownLock.Unlock()
+	var errChan = make(chan error, 1)
+	go func() {
+		errChan <- listen()
+	}()
+	select {
+	case err := <-errChan:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		app.shutdown(context.Background())
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), app.shutdownTimeout())
+		defer cancel()
+		return app.shutdown(shutdownCtx)
 	}
-	return nil
 }
 
-func (app *server) isConfigFile(f string) bool {
+// shutdownTimeout get the time to wait for the active requests while shutting down the server. The value is read
+// from the 'shutdownTimeout' attribute of the server config in seconds, the default value is 30 seconds.
+func (app *Application) shutdownTimeout() time.Duration {
+	if app.config != nil && app.config.ServerConfig != nil && app.config.ServerConfig.ShutdownTimeout > 0 {
+		return time.Duration(app.config.ServerConfig.ShutdownTimeout) * time.Second
+	}
+	return 30 * time.Second
+}
+
+func (app *Application) shutdown(ctx context.Context) error {
+	app.shutdownLock.Lock()
+	if app.isShutdown {
+		app.shutdownLock.Unlock()
+		return nil
+	}
+	app.isShutdown = true
+	srv := app.httpServer
+	app.shutdownLock.Unlock()
+	var result error
+	if srv != nil {
+		result = srv.Shutdown(ctx)
+	}
+	// execute the shutdown events in the reverse order of the registration
+	for i := len(app.appShutEvents) - 1; i >= 0; i-- {
+		if err := app.appShutEvents[i](); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+func (app *Application) isConfigFile(f string) bool {
 	if runtime.GOOS == "windows" {
 		return strings.EqualFold(app.mapPath("/config.xml"), f)
 	} else {
@@ -372,38 +561,44 @@ func (app *server) isConfigFile(f string) bool {
 	}
 }
 
-func (app *server) isInViewFolder(f string) bool {
+func (app *Application) isInViewFolder(f string) bool {
 	viewPath := app.viewFolder()
 	return strings.HasPrefix(f, viewPath)
 }
 
-// isStaticRequest check the current request is indicate to static path
-func (app *server) isStaticRequest(req *http.Request) bool {
-	var reqUrl string
-	if runtime.GOOS == "windows" {
-		reqUrl = strings.ToLower(req.URL.Path)
-	} else {
-		reqUrl = req.URL.Path
-	}
+// staticURL check the url path is the static file or under the static paths, and get the url path in the letter case of the static path setting
+func (app *Application) staticURL(urlPath string) (string, bool) {
+	var filePath = strings.TrimRight(urlPath, "/")
 	for _, f := range app.staticFiles {
-		if f == reqUrl {
-			return true
+		if app.equalPath(filePath, f) {
+			return strAdd(f, urlPath[len(filePath):]), true
 		}
 	}
 	for _, p := range app.staticPaths {
-		if strings.HasPrefix(reqUrl, p) {
-			return true
+		if len(urlPath) >= len(p) && app.equalPath(urlPath[:len(p)], p) {
+			return strAdd(p, urlPath[len(p):]), true
+		}
+		// the static path without trailing slash
+		if app.equalPath(urlPath, p[:len(p)-1]) {
+			return p[:len(p)-1], true
 		}
 	}
-	return false
+	return "", false
 }
 
-func (app *server) viewFolder() string {
+func (app *Application) equalPath(path1, path2 string) bool {
+	if app.routing.MatchCase {
+		return path1 == path2
+	}
+	return strings.EqualFold(path1, path2)
+}
+
+func (app *Application) viewFolder() string {
 	return app.mapPath("/views")
 }
 
 // regRequestFilter register context filter to the featured request step
-func (app *server) regRequestFilter(ev requestEvent, h CtxFilter) {
+func (app *Application) regRequestFilter(ev requestEvent, h CtxFilter) {
 	app.assertNotLocked()
 	hs, ok := app.httpReqEvents[ev]
 	if ok && h != nil {
@@ -412,7 +607,7 @@ func (app *server) regRequestFilter(ev requestEvent, h CtxFilter) {
 	}
 }
 
-func (app *server) execReqEvents(ev requestEvent, ctx *Context) {
+func (app *Application) execReqEvents(ev requestEvent, ctx *Context) {
 	if ctx == nil || ctx.ended {
 		return
 	}
@@ -426,17 +621,25 @@ func (app *server) execReqEvents(ev requestEvent, ctx *Context) {
 	}
 }
 
-func newServer(webRoot string) *server {
-	var app = &server{
+func newServer(webRoot string) *Application {
+	var app = &Application{
 		webRoot:       webRoot,
 		locked:        false,
 		errorHandlers: make(map[int]ErrorHandler),
 		routing:   newRouteTree(),
 	}
+	if app.routing.MatchCase {
+		app.casePolicy = PolicyStrict
+	} else {
+		app.casePolicy = PolicyLenient
+	}
 	app.views = make(map[string]*view)
 	app.filters = make(map[string][]CtxFilter)
 	app.viewExt = ".html"
 	app.sessionProvides = make(map[string]SessionProvider)
+	app.formatters = defaultFormatters()
+	app.sessionCodecs = defaultSessionCodecs()
+	app.namedRoutes = make(map[string]*routeConfig)
 	app.httpReqEvents = make(map[requestEvent][]CtxFilter, 8)
 	app.httpReqEvents[beforeCheck] = nil
 	app.httpReqEvents[afterCheck] = []CtxFilter{dangerCheck}
@@ -453,6 +656,9 @@ func newServer(webRoot string) *server {
 	app.onAppInit(app.initNs)
 	app.onAppInit(app.initSessionMgr)
 	app.onAppInit(app.initCacheMgr)
+	app.onAppShutdown(app.closeWatcher)
+	app.onAppShutdown(app.closeSessionMgr)
+	app.onAppShutdown(app.closeCacheMgr)
 	w, err := NewWatcher()
 	if err != nil {
 		panic(err)
@@ -0,0 +1,34 @@
+package wemvc
+
+// ServerConfig the http server config struct. The timeouts are set in seconds, the shutdownTimeout is the time to wait
+// for the active requests while shutting down the server (30 seconds by default).
+// The url policies of the trailing slash and the letter case are 'lenient', 'strict' or 'redirect'.
+// The weak ETag of the content results is generated if etag is true.
+// The response compression is set by the child element <compression>
+type ServerConfig struct {
+	ReadTimeout       int64              `xml:"readTimeout,attr"`
+	ReadHeaderTimeout int64              `xml:"readHeaderTimeout,attr"`
+	WriteTimeout      int64              `xml:"writeTimeout,attr"`
+	IdleTimeout       int64              `xml:"idleTimeout,attr"`
+	ShutdownTimeout   int64              `xml:"shutdownTimeout,attr"`
+	TrailingSlash     string             `xml:"trailingSlash,attr"`
+	LetterCase        string             `xml:"letterCase,attr"`
+	ETag              bool               `xml:"etag,attr"`
+	Compression       *CompressionConfig `xml:"compression"`
+}
+
+// CompressionConfig the response compression config, for example:
+//
+//	<server>
+//		<compression enabled="true" level="6" minSize="1024" excludeTypes="text/csv,application/x-ndjson"/>
+//	</server>
+//
+// The level is between -2 (huffman only) and 9 (best compression), the default level is used if it is 0.
+// The response that is smaller than the minSize (1024 by default) is not compressed, and the already compressed
+// content types like images, videos and archives are never compressed
+type CompressionConfig struct {
+	Enabled      bool   `xml:"enabled,attr"`
+	Level        int    `xml:"level,attr"`
+	MinSize      int    `xml:"minSize,attr"`
+	ExcludeTypes string `xml:"excludeTypes,attr"`
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 )
 
-func (app *server) error404(req *http.Request) *ContentResult {
+func (app *Application) error404(req *http.Request) *ContentResult {
 	return renderError(
 		404,
 		"The resource you are looking for has been removed, had its name changed, or is temporarily unavailable",
@@ -15,7 +15,7 @@ func (app *server) error404(req *http.Request) *ContentResult {
 	)
 }
 
-func (app *server) error403(req *http.Request) *ContentResult {
+func (app *Application) error403(req *http.Request) *ContentResult {
 	return renderError(
 		403,
 		"The server understood the request but refuses to authorize it",
@@ -24,7 +24,7 @@ func (app *server) error403(req *http.Request) *ContentResult {
 	)
 }
 
-func (app *server) handleErrorReq(req *http.Request, code int, title ...string) *ContentResult {
+func (app *Application) handleErrorReq(req *http.Request, code int, title ...string) *ContentResult {
 	var handler = app.errorHandlers[code]
 	if handler != nil {
 		return handler(req)
@@ -43,7 +43,7 @@ func (app *server) handleErrorReq(req *http.Request, code int, title ...string)
 	return app.error404(req)
 }
 
-func (app *server) panicRecover(res http.ResponseWriter, req *http.Request) {
+func (app *Application) panicRecover(res http.ResponseWriter, req *http.Request) {
 	rec := recover()
 	if rec == nil {
 		return
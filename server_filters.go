@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -27,11 +26,36 @@ func dangerCheck(ctx *Context) {
 // serveStatic serve the current request as static request
 func serveStatic(ctx *Context) {
 	physicalFile := ""
-	var f = ctx.app.mapPath(ctx.req.URL.Path)
+	var urlPath = ctx.staticURL
+	if len(urlPath) == 0 {
+		urlPath = ctx.req.URL.Path
+	}
+	var f = ctx.app.mapPath(urlPath)
 	stat, err := os.Stat(f)
 	if err == nil {
+		// the canonical url of the directory ends with '/', and the canonical url of the file does not
+		var canonicalPath = urlPath
+		if stat.IsDir() && !strings.HasSuffix(urlPath, "/") {
+			canonicalPath = strAdd(urlPath, "/")
+		} else if !stat.IsDir() && strings.HasSuffix(urlPath, "/") {
+			canonicalPath = strings.TrimRight(urlPath, "/")
+		}
+		if canonicalPath != urlPath {
+			switch ctx.app.slashPolicy {
+			case PolicyStrict:
+				ctx.EndContext()
+				return
+			case PolicyLenient:
+				canonicalPath = urlPath
+			}
+		}
+		if canonicalPath != ctx.req.URL.Path && (canonicalPath != urlPath || ctx.app.casePolicy == PolicyRedirect) {
+			ctx.Result = redirectCanonical(ctx.req, canonicalPath)
+			ctx.EndContext()
+			return
+		}
 		if stat.IsDir() {
-			absolutePath := ctx.req.URL.Path
+			absolutePath := urlPath
 			if !strings.HasSuffix(absolutePath, "/") {
 				absolutePath = strAdd(absolutePath, "/")
 			}
@@ -68,12 +92,25 @@ func handleRoute(ctx *Context) {
 	}
 
 	var urlPath = ctx.Route.RouteURL
-	if len(urlPath) > 1 && strings.HasSuffix(urlPath, "/") {
-		urlPath = strings.TrimRight(urlPath, "/")
-	}
-	//var resp ActionResult
-	cInfo, routeData, err := ctx.app.routing.lookup(ctx.Route.RouteURL, strings.ToLower(ctx.req.Method))
-	if err == nil && cInfo != nil {
+	m, err := ctx.app.routing.match(urlPath, strings.ToLower(ctx.req.Method), ctx.app.casePolicy == PolicyRedirect)
+	// the url policy is not applied to the route url that is rewritten by the filters
+	var checkURL = urlPath == ctx.req.URL.Path
+	if err == nil && m.ctrl != nil {
+		if checkURL && m.slashDiff && ctx.app.slashPolicy == PolicyStrict {
+			ctx.EndContext()
+			return
+		}
+		if checkURL && ((m.slashDiff && ctx.app.slashPolicy == PolicyRedirect) || m.caseDiff) {
+			var canonicalPath = m.cleanPath
+			if ctx.app.slashPolicy == PolicyLenient && len(canonicalPath) > 1 && strings.HasSuffix(urlPath, "/") {
+				canonicalPath = strAdd(canonicalPath, "/")
+			}
+			ctx.Result = redirectCanonical(ctx.req, canonicalPath)
+			ctx.EndContext()
+			return
+		}
+		var cInfo = m.ctrl
+		var routeData = m.routeData
 		if routeData == nil {
 			routeData = make(map[string]string)
 		}
@@ -84,19 +121,28 @@ func handleRoute(ctx *Context) {
 		}
 		var method = strings.ToLower(ctx.req.Method)
 		// find the action method in controller
-		if actionMethod := cInfo.findActionName(action, method , ctx.app.friendlyActionName); len(actionMethod) > 0 {
+		if actionMethod := cInfo.findActionName(action, method, ctx.app.routing.friendlyAction); len(actionMethod) > 0 {
 			ctx.Route.NsName = ns
 			ctx.Ctrl = &CtxController{
 				ControllerName:   cInfo.CtrlName,
 				controllerType:   cInfo.CtrlType,
 				ActionName:       action,
 				ActionMethodName: actionMethod,
+				actionParams:     cInfo.ActionParams[actionMethod],
 			}
 			routeData["controller"] = ctx.Ctrl.ControllerName
 			ctx.Route.RouteData = routeData
 			return
 		}
 	}
+	if errMethod, ok := err.(*errMethodNotAllowed); ok {
+		res := ctx.app.handleErrorReq(ctx.Request(), 405)
+		if res != nil {
+			res.Header()["Allow"] = strings.Join(errMethod.allowed, ", ")
+		}
+		ctx.Result = res
+		return
+	}
 	if err != nil {
 		ctx.Result = ctx.app.handleErrorReq(ctx.Request(), 500, err.Error())
 		return
@@ -109,15 +155,13 @@ func execFilters(ctx *Context) {
 		return
 	}
 	urlPath := ctx.Route.RouteURL
-	if !ctx.app.routing.MatchCase {
-		urlPath = strings.ToLower(urlPath)
-	}
+	matchCase := ctx.app.routing.MatchCase
 	if len(ctx.Route.NsName) < 1 {
-		ctx.app.execFilters(urlPath, ctx)
+		ctx.app.execFilters(urlPath, matchCase, ctx)
 	} else {
 		ns, ok := ctx.app.namespaces[ctx.Route.NsName]
 		if ok && ns != nil {
-			ns.execFilters(urlPath, ctx)
+			ns.execFilters(urlPath, matchCase, ctx)
 		}
 	}
 }
@@ -150,21 +194,19 @@ func execAction(ctx *Context) {
 	}
 	//parse form
 	if ctx.req.Method == "POST" || ctx.req.Method == "PUT" || ctx.req.Method == "PATCH" {
-		if ctx.req.MultipartForm != nil {
-			var size int64
-			var maxSize = ctx.app.config.GetSetting("MaxFormSize")
-			if len(maxSize) < 1 {
-				size = 10485760
-			} else {
-				size, _ = strconv.ParseInt(maxSize, 10, 64)
-			}
-			ctx.req.ParseMultipartForm(size)
-		} else {
-			ctx.req.ParseForm()
+		if err := ctx.parseForm(); err != nil {
+			ctx.Result = ctx.app.handleErrorReq(ctx.req, err.(*BindError).StatusCode, err.Error())
+			return
 		}
 	}
+	// bind the action parameters
+	args, err := ctx.bindActionParams(ctx.Ctrl.actionParams)
+	if err != nil {
+		ctx.Result = ctx.app.handleErrorReq(ctx.req, 400, err.Error())
+		return
+	}
 	// call action method
-	values := ctx.Ctrl.actionMethod.Call(nil)
+	values := ctx.Ctrl.actionMethod.Call(args)
 	if len(values) == 1 {
 		ctx.Result = values[0].Interface()
 	}
@@ -7,7 +7,7 @@ import (
 )
 
 type fsConfigHandler struct {
-	app *server
+	app *Application
 }
 
 func (d *fsConfigHandler) CanHandle(path string) bool {
@@ -26,12 +26,12 @@ func (d *fsConfigHandler) Handle(ev *fsnotify.Event) {
 }
 
 type fsNsConfigHandler struct {
-	app *server
+	app *Application
 	ns  *NsSection
 }
 
 func (d *fsNsConfigHandler) CanHandle(path string) bool {
-	for _, ns := range app.namespaces {
+	for _, ns := range d.app.namespaces {
 		if ns.isConfigFile(path) {
 			d.ns = ns
 			return true
@@ -45,7 +45,7 @@ func (d *fsNsConfigHandler) Handle(ev *fsnotify.Event) {
 }
 
 type fsViewHandler struct {
-	app *server
+	app *Application
 }
 
 func (d *fsViewHandler) CanHandle(path string) bool {
@@ -67,7 +67,7 @@ func (d *fsViewHandler) Handle(ev *fsnotify.Event) {
 }
 
 type fsNsViewHandler struct {
-	app *server
+	app *Application
 	ns  *NsSection
 }
 
@@ -11,6 +11,7 @@ type routeConfig struct {
 	routePath string
 	c         interface{}
 	action    string
+	methods   []string
 }
 
 func genFriendlyActionName(methodName string) string {
@@ -63,16 +64,29 @@ func (r *routeConfig) genCtrlInfo(friendlyAction bool) *controllerInfo {
 	if numMethod < 1 {
 		panic(errCtrlNoAction(typeName))
 	}
+	var paramNames map[string][]string
+	if declarer, ok := r.c.(ActionParams); ok {
+		paramNames = declarer.ActionParams()
+	}
 	methods := make([]string, 0, numMethod)
+	actionParams := make(map[string][]*actionParam)
 	for i := 0; i < numMethod; i++ {
 		methodInfo := t.Method(i)
-		numIn := methodInfo.Type.NumIn()
-		numOut := methodInfo.Type.NumOut()
-		if numIn != 1 || numOut != 1 {
+		if methodInfo.Type.NumOut() != 1 || methodInfo.Name == actionParamsMethod {
+			continue
+		}
+		params, ok, err := newActionParams(t, methodInfo, paramNames[methodInfo.Name])
+		if err != nil {
+			panic(err)
+		}
+		if !ok {
 			continue
 		}
 		methodName := methodInfo.Name
 		methods = append(methods, methodName)
+		if len(params) > 0 {
+			actionParams[methodName] = params
+		}
 	}
 	if len(methods) < 1 {
 		panic(errCtrlNoAction(typeName))
@@ -92,6 +106,8 @@ func (r *routeConfig) genCtrlInfo(friendlyAction bool) *controllerInfo {
 		CtrlName:      getControllerName(t),
 		CtrlType:      t,
 		Actions:       actions,
+		ActionParams:  actionParams,
 		DefaultAction: r.action,
+		Methods:       r.methods,
 	}
 }
\ No newline at end of file
@@ -25,8 +25,7 @@ func Test_controllerInfo_findActionName(t *testing.T) {
 			"get-index": "GetIndex",
 		},
 	}
-	println(cInfo.findActionName("GET", "index", true))
-	if cInfo.findActionName("GET", "index", true) != "GetIndex" {
+	if cInfo.findActionName("index", "GET", true) != "GetIndex" {
 		t.Fatal("Test Failed")
 	}
 }
\ No newline at end of file
@@ -11,6 +11,8 @@ type SessionConfig struct {
 	HttpOnly        bool   `xml:"httpOnly,attr"`
 	CookieLifeTime  int    `xml:"cookieLifeTime,attr"`
 	ProviderConfig  string `xml:"providerConfig,attr"`
+	Codec           string `xml:"codec,attr"`
 	Domain          string `xml:"domain,attr"`
 	SessionIDLength int64  `xml:"sessionIDLength,attr"`
+	RequestLock     bool   `xml:"requestLock,attr"`
 }
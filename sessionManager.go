@@ -6,14 +6,26 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 	//"errors"
 )
 
 // SessionManager the session manager struct
 type SessionManager struct {
-	provider SessionProvider
-	config   *SessionConfig
+	provider  SessionProvider
+	config    *SessionConfig
+	gcLock    sync.Mutex
+	gcTimer   *time.Timer
+	gcStopped bool
+	reqLock   sync.Mutex
+	reqLocks  map[string]*requestLock
+}
+
+// requestLock the lock of the session that is held by the request
+type requestLock struct {
+	mu   sync.Mutex
+	refs int
 }
 
 // NewSessionManager Create new Manager with provider name and json config string.
@@ -21,14 +33,13 @@ type SessionManager struct {
 // 1. cookie
 // 2. file
 // 3. memory
-// 4. redis
-// 5. mysql
+// 4. sql
 // xml config:
 // 1. is https  default false
 // 2. hashfunc  default sha1
 // 3. hashkey default beegosessionkey
 // 4. maxage default is none
-func (app *server) NewSessionManager(provideName string, config *SessionConfig) (*SessionManager, error) {
+func (app *Application) NewSessionManager(provideName string, config *SessionConfig) (*SessionManager, error) {
 	provider, ok := app.sessionProvides[provideName]
 	if !ok {
 		return nil, fmt.Errorf("session: unknown provide %q (forgotten import?)", provideName)
@@ -37,6 +48,20 @@ func (app *server) NewSessionManager(provideName string, config *SessionConfig)
 	if config.MaxLifetime == 0 {
 		config.MaxLifetime = config.GcLifetime
 	}
+	if cp, ok := provider.(clientSessionProvider); ok {
+		cp.setCookieConfig(config)
+	}
+	if cp, ok := provider.(codecSessionProvider); ok {
+		var codecName = config.Codec
+		if len(codecName) == 0 {
+			codecName = "gob"
+		}
+		codec, ok := app.sessionCodecs[codecName]
+		if !ok {
+			return nil, errSessionCodec(codecName)
+		}
+		cp.setCodec(codec)
+	}
 	err := provider.SessionInit(config.MaxLifetime, config.ProviderConfig)
 	if err != nil {
 		return nil, err
@@ -49,6 +74,7 @@ func (app *server) NewSessionManager(provideName string, config *SessionConfig)
 	return &SessionManager{
 		provider: provider,
 		config:   config,
+		reqLocks: make(map[string]*requestLock),
 	}, nil
 }
 
@@ -82,6 +108,14 @@ func (manager *SessionManager) isSecure(req *http.Request) bool {
 	return true
 }
 
+// secureSession set the secure flag of the cookie session store by the request, the session cookie is written by the
+// session store when it is released
+func (manager *SessionManager) secureSession(session SessionStore, r *http.Request) {
+	if st, ok := session.(*CookieSessionStore); ok {
+		st.secure = manager.isSecure(r)
+	}
+}
+
 func (manager *SessionManager) sessionID() (string, error) {
 	uuid := NewUUID()
 	return uuid.ShortString(), nil
@@ -95,7 +129,13 @@ func (manager *SessionManager) SessionStart(w http.ResponseWriter, r *http.Reque
 		return nil, err
 	}
 
-	if sessionID != "" && manager.provider.SessionExist(sessionID) {
+	if cp, ok := manager.provider.(clientSessionProvider); ok && sessionID != "" {
+		// the cookie value is only decrypted once
+		if session = cp.readCookie(sessionID); session != nil {
+			manager.secureSession(session, r)
+			return session, nil
+		}
+	} else if sessionID != "" && manager.provider.SessionExist(sessionID) {
 		return manager.provider.SessionRead(sessionID)
 	}
 
@@ -106,6 +146,11 @@ func (manager *SessionManager) SessionStart(w http.ResponseWriter, r *http.Reque
 	}
 
 	session, err = manager.provider.SessionRead(sessionID)
+	if manager.clientSide() {
+		// the session cookie is written when the session is released
+		manager.secureSession(session, r)
+		return
+	}
 	cookie := &http.Cookie{
 		Name:     manager.config.CookieName,
 		Value:    url.QueryEscape(sessionID),
@@ -147,7 +192,7 @@ func (manager *SessionManager) SessionDestroy(w http.ResponseWriter, r *http.Req
 			Expires:  expiration,
 			MaxAge:   -1,
 		}
-		http.SetCookie(w, cookie)
+		replaceCookie(w, cookie)
 	}
 }
 
@@ -161,7 +206,23 @@ func (manager *SessionManager) GetSessionStore(sid string) (sessions SessionStor
 // it can do gc in times after gc lifetime.
 func (manager *SessionManager) GC() {
 	manager.provider.SessionGC()
-	time.AfterFunc(time.Duration(manager.config.GcLifetime)*time.Second, func() { manager.GC() })
+	manager.gcLock.Lock()
+	defer manager.gcLock.Unlock()
+	if manager.gcStopped {
+		return
+	}
+	manager.gcTimer = time.AfterFunc(time.Duration(manager.config.GcLifetime)*time.Second, func() { manager.GC() })
+}
+
+// StopGC Stop the session gc process started by GC.
+func (manager *SessionManager) StopGC() {
+	manager.gcLock.Lock()
+	defer manager.gcLock.Unlock()
+	manager.gcStopped = true
+	if manager.gcTimer != nil {
+		manager.gcTimer.Stop()
+		manager.gcTimer = nil
+	}
 }
 
 // SessionRegenerateID Regenerate a session id for this SessionStore who's id is saving in http request.
@@ -170,6 +231,19 @@ func (manager *SessionManager) SessionRegenerateID(w http.ResponseWriter, r *htt
 	if err != nil {
 		return
 	}
+	if manager.clientSide() {
+		// the new session id is saved in the session cookie
+		if cookie, err := r.Cookie(manager.config.CookieName); err == nil && len(cookie.Value) > 0 {
+			session, _ = manager.provider.SessionRegenerate(cookie.Value, sid)
+		} else {
+			session, _ = manager.provider.SessionRead(sid)
+		}
+		if session != nil {
+			manager.secureSession(session, r)
+			session.SessionRelease(w)
+		}
+		return
+	}
 	cookie, err := r.Cookie(manager.config.CookieName)
 	if err != nil || cookie.Value == "" {
 		//delete old cookie
@@ -199,6 +273,43 @@ func (manager *SessionManager) SessionRegenerateID(w http.ResponseWriter, r *htt
 	return
 }
 
+// lockRequest lock the session of the request if the 'requestLock' attribute of the session config is true, so the
+// concurrent requests of the same session are executed one by one and the changes of the session are not lost.
+// The session is locked in the process, and the returned function releases the lock. The nil is returned if the
+// session is not locked, like the new session or the session that is saved in the client cookie
+func (manager *SessionManager) lockRequest(r *http.Request) func() {
+	if !manager.config.RequestLock || manager.clientSide() {
+		return nil
+	}
+	sid, err := manager.getSessionID(r)
+	if err != nil || len(sid) == 0 {
+		return nil
+	}
+	manager.reqLock.Lock()
+	l, ok := manager.reqLocks[sid]
+	if !ok {
+		l = &requestLock{}
+		manager.reqLocks[sid] = l
+	}
+	l.refs++
+	manager.reqLock.Unlock()
+	l.mu.Lock()
+	return func() {
+		manager.reqLock.Lock()
+		defer manager.reqLock.Unlock()
+		l.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(manager.reqLocks, sid)
+		}
+	}
+}
+
+// clientSide check the session is saved in the client cookie by the provider
+func (manager *SessionManager) clientSide() bool {
+	_, ok := manager.provider.(clientSessionProvider)
+	return ok
+}
+
 // GetActiveSession Get all active sessions count number.
 func (manager *SessionManager) GetActiveSession() int {
 	return manager.provider.SessionAll()
@@ -44,7 +44,7 @@ func (prov *memSessionProvider) SessionRead(sid string) (SessionStore, error) {
 	}
 	prov.lock.RUnlock()
 	prov.lock.Lock()
-	newStore := &MemSessionStore{sid: sid, timeAccessed: time.Now(), value: make(map[interface{}]interface{})}
+	newStore := &MemSessionStore{sid: sid, timeAccessed: time.Now(), sessionValues: sessionValues{value: make(map[interface{}]interface{})}}
 	element := prov.list.PushFront(newStore)
 	prov.sessions[sid] = element
 	prov.lock.Unlock()
@@ -76,7 +76,7 @@ func (prov *memSessionProvider) SessionRegenerate(oldsid, sid string) (SessionSt
 	}
 	prov.lock.RUnlock()
 	prov.lock.Lock()
-	newStore := &MemSessionStore{sid: sid, timeAccessed: time.Now(), value: make(map[interface{}]interface{})}
+	newStore := &MemSessionStore{sid: sid, timeAccessed: time.Now(), sessionValues: sessionValues{value: make(map[interface{}]interface{})}}
 	element := prov.list.PushFront(newStore)
 	prov.sessions[sid] = element
 	prov.lock.Unlock()
@@ -2,61 +2,34 @@ package wemvc
 
 import (
 	"net/http"
-	"sync"
 	"time"
 )
 
-// SessionStore the session store interface
+// SessionStore the session store interface. The session is saved at the end of the request only if the values are
+// changed by Set, Delete or Flush. The changes of the pointer, map or slice values that are got by Get are not tracked,
+// so Set the value again or call MarkDirty to save them
 type SessionStore interface {
-	Set(key, value interface{}) error     //set session value
-	Get(key interface{}) interface{}      //get session value
-	Delete(key interface{}) error         //delete session value
-	SessionID() string                    //back current sessionID
-	SessionRelease(w http.ResponseWriter) // release the resource & save data to provider & return the data
-	Flush() error                         //delete all data
+	Set(key, value interface{}) error            //set session value
+	Get(key interface{}) interface{}             //get session value
+	Delete(key interface{}) error                //delete session value
+	Keys() []interface{}                         //get the keys of the session values
+	GetString(key string) string                 //get session value as string
+	GetInt(key string) int                       //get session value as int
+	GetBool(key string) bool                     //get session value as bool
+	GetTime(key string) time.Time                //get session value as time
+	GetObject(key string, dst interface{}) error //get session value and store it in dst
+	SessionID() string                           //back current sessionID
+	SessionRelease(w http.ResponseWriter)        // release the resource & save data to provider & return the data
+	Flush() error                                //delete all data
+	MarkDirty()                                  //save the session at the end of the request
 }
 
 // MemSessionStore memory session store.
 // it saved sessions in a map in memory.
 type MemSessionStore struct {
-	sid          string                      //session id
-	timeAccessed time.Time                   //last access time
-	value        map[interface{}]interface{} //session store
-	lock         sync.RWMutex
-}
-
-// Set value to memory session
-func (st *MemSessionStore) Set(key, value interface{}) error {
-	st.lock.Lock()
-	defer st.lock.Unlock()
-	st.value[key] = value
-	return nil
-}
-
-// Get value from memory session by key
-func (st *MemSessionStore) Get(key interface{}) interface{} {
-	st.lock.RLock()
-	defer st.lock.RUnlock()
-	if v, ok := st.value[key]; ok {
-		return v
-	}
-	return nil
-}
-
-// Delete in memory session by key
-func (st *MemSessionStore) Delete(key interface{}) error {
-	st.lock.Lock()
-	defer st.lock.Unlock()
-	delete(st.value, key)
-	return nil
-}
-
-// Flush clear all values in memory session
-func (st *MemSessionStore) Flush() error {
-	st.lock.Lock()
-	defer st.lock.Unlock()
-	st.value = make(map[interface{}]interface{})
-	return nil
+	sessionValues           //session store
+	sid           string    //session id
+	timeAccessed  time.Time //last access time
 }
 
 // SessionID get this id of memory session store
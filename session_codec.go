@@ -0,0 +1,332 @@
+package wemvc
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SessionCodec the codec that serializes the session values for the providers that save the sessions out of the
+// process, like the file, the cookie and the sql session providers
+type SessionCodec interface {
+	Encode(values map[interface{}]interface{}) ([]byte, error)
+	Decode(data []byte) (map[interface{}]interface{}, error)
+}
+
+// codecSessionProvider the provider that serializes the session values by the session codec
+type codecSessionProvider interface {
+	SessionProvider
+	setCodec(codec SessionCodec)
+}
+
+// GobSessionCodec the session codec of encoding/gob. The types of the values that are not the built-in types should
+// be registered by gob.Register
+type GobSessionCodec struct{}
+
+// Encode encode the session values by gob
+func (GobSessionCodec) Encode(values map[interface{}]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decode the session values by gob
+func (GobSessionCodec) Decode(data []byte) (map[interface{}]interface{}, error) {
+	var values map[interface{}]interface{}
+	if len(data) > 0 {
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+			return nil, err
+		}
+	}
+	if values == nil {
+		values = make(map[interface{}]interface{})
+	}
+	return values, nil
+}
+
+// JSONSessionCodec the session codec of encoding/json. Only the string keys are supported, the numbers are decoded
+// as json.Number and the objects are decoded as map[string]interface{}, so the values should be read by the typed
+// helpers of the session store like GetInt and GetObject
+type JSONSessionCodec struct{}
+
+// Encode encode the session values by json
+func (JSONSessionCodec) Encode(values map[interface{}]interface{}) ([]byte, error) {
+	var m = make(map[string]interface{}, len(values))
+	for k, v := range values {
+		key, ok := k.(string)
+		if !ok {
+			return nil, errSessionKeyType(k)
+		}
+		m[key] = v
+	}
+	return json.Marshal(m)
+}
+
+// Decode decode the session values by json
+func (JSONSessionCodec) Decode(data []byte) (map[interface{}]interface{}, error) {
+	var values = make(map[interface{}]interface{})
+	if len(data) == 0 {
+		return values, nil
+	}
+	var m map[string]interface{}
+	var decoder = json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// defaultSessionCodecs the gob and json session codecs
+func defaultSessionCodecs() map[string]SessionCodec {
+	return map[string]SessionCodec{
+		"gob":  GobSessionCodec{},
+		"json": JSONSessionCodec{},
+	}
+}
+
+// regSessionCodec register the session codec, the codec of the same name is replaced
+func (app *Application) regSessionCodec(name string, codec SessionCodec) {
+	app.assertNotLocked()
+	if codec == nil {
+		panic(errSessionCodecNil)
+	}
+	app.sessionCodecs[name] = codec
+}
+
+// dirtySessionStore the session store that tracks the changes of the values, the session store is released at the
+// end of the request only if it is dirty
+type dirtySessionStore interface {
+	Dirty() bool
+}
+
+// errSessionStore the session store that reports the error of saving the values when it is released
+type errSessionStore interface {
+	Err() error
+}
+
+// sessionValues the session values that are shared by the session stores, it implements the value methods and the
+// typed helpers of the SessionStore interface, and tracks the changes of the values
+type sessionValues struct {
+	value map[interface{}]interface{}
+	lock  sync.RWMutex
+	dirty bool
+	err   error
+}
+
+// Set value to session
+func (sv *sessionValues) Set(key, value interface{}) error {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	sv.value[key] = value
+	sv.dirty = true
+	return nil
+}
+
+// Get value from session by key
+func (sv *sessionValues) Get(key interface{}) interface{} {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.value[key]
+}
+
+// Delete value in session by key
+func (sv *sessionValues) Delete(key interface{}) error {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	if _, ok := sv.value[key]; ok {
+		delete(sv.value, key)
+		sv.dirty = true
+	}
+	return nil
+}
+
+// Flush clear all values in session
+func (sv *sessionValues) Flush() error {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	if len(sv.value) > 0 {
+		sv.value = make(map[interface{}]interface{})
+		sv.dirty = true
+	}
+	return nil
+}
+
+// Dirty check the values are changed since the session store is read or saved
+func (sv *sessionValues) Dirty() bool {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.dirty
+}
+
+// MarkDirty mark the values as changed, so the session is saved at the end of the request. It is used when the value
+// that is got by Get is changed in place
+func (sv *sessionValues) MarkDirty() {
+	sv.setDirty(true)
+}
+
+// Err get the error of saving the values when the session store is released the last time, the values are not saved
+// if it is not nil
+func (sv *sessionValues) Err() error {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	return sv.err
+}
+
+func (sv *sessionValues) setErr(err error) {
+	sv.lock.Lock()
+	sv.err = err
+	sv.lock.Unlock()
+}
+
+func (sv *sessionValues) setDirty(dirty bool) {
+	sv.lock.Lock()
+	sv.dirty = dirty
+	sv.lock.Unlock()
+}
+
+// snapshot get the copy of the values to save and reset the dirty state, the dirty state should be set again if the
+// values can not be saved
+func (sv *sessionValues) snapshot() map[interface{}]interface{} {
+	sv.lock.Lock()
+	defer sv.lock.Unlock()
+	var values = make(map[interface{}]interface{}, len(sv.value))
+	for k, v := range sv.value {
+		values[k] = v
+	}
+	sv.dirty = false
+	return values
+}
+
+// Keys get the keys of the session values
+func (sv *sessionValues) Keys() []interface{} {
+	sv.lock.RLock()
+	defer sv.lock.RUnlock()
+	var keys = make([]interface{}, 0, len(sv.value))
+	for k := range sv.value {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// GetString get the session value as string, the empty string is returned if the value does not exist
+func (sv *sessionValues) GetString(key string) string {
+	switch v := sv.Get(key).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// GetInt get the session value as int, 0 is returned if the value does not exist or it is not an integer
+func (sv *sessionValues) GetInt(key string) int {
+	switch v := sv.Get(key).(type) {
+	case json.Number:
+		i, _ := strconv.Atoi(v.String())
+		return i
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	case nil:
+		return 0
+	default:
+		var rv = reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return int(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			return int(rv.Float())
+		}
+	}
+	return 0
+}
+
+// GetBool get the session value as bool, false is returned if the value does not exist or it is not a bool
+func (sv *sessionValues) GetBool(key string) bool {
+	switch v := sv.Get(key).(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// GetTime get the session value as time.Time, the string value is parsed by RFC 3339.
+// The zero time is returned if the value does not exist or it is not a time
+func (sv *sessionValues) GetTime(key string) time.Time {
+	switch v := sv.Get(key).(type) {
+	case time.Time:
+		return v
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	case string:
+		t, _ := time.Parse(time.RFC3339Nano, v)
+		return t
+	}
+	return time.Time{}
+}
+
+// GetObject get the session value and store it in the value pointed to by dst. The value is converted by json if
+// it can not be assigned to dst, like the object that is decoded by the json session codec.
+// The errSessionKeyNotFound is returned if the value does not exist
+func (sv *sessionValues) GetObject(key string, dst interface{}) error {
+	var rv = reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errSessionObjectDst
+	}
+	var v = sv.Get(key)
+	if v == nil {
+		return errSessionKeyNotFound(key)
+	}
+	var value = reflect.ValueOf(v)
+	if value.Type().AssignableTo(rv.Elem().Type()) {
+		rv.Elem().Set(value)
+		return nil
+	}
+	if value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Type().AssignableTo(rv.Elem().Type()) {
+		rv.Elem().Set(value.Elem())
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// providerCodec the session codec of the provider, the gob codec is used if the codec is not set
+type providerCodec struct {
+	codec SessionCodec
+}
+
+func (pc *providerCodec) setCodec(codec SessionCodec) {
+	pc.codec = codec
+}
+
+func (pc *providerCodec) valuesCodec() SessionCodec {
+	if pc.codec == nil {
+		return GobSessionCodec{}
+	}
+	return pc.codec
+}
@@ -0,0 +1,119 @@
+package wemvc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+type sessionUser struct {
+	Name  string
+	Roles []string
+}
+
+func Test_SessionCodec(t *testing.T) {
+	var values = map[interface{}]interface{}{"name": "steve", "count": 12, "admin": true}
+	for name, codec := range defaultSessionCodecs() {
+		data, err := codec.Encode(values)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		decoded, err := codec.Decode(data)
+		if err != nil || len(decoded) != 3 || decoded["name"] != "steve" || decoded["admin"] != true {
+			t.Error(name, "test 1 failed", err, decoded)
+		}
+		if decoded, err = codec.Decode(nil); err != nil || decoded == nil || len(decoded) != 0 {
+			t.Error(name, "test 2 failed", err)
+		}
+	}
+	if _, err := (JSONSessionCodec{}).Encode(map[interface{}]interface{}{1: "one"}); err == nil {
+		t.Error("test 3 failed")
+	}
+	decoded, _ := JSONSessionCodec{}.Decode([]byte(`{"count":12}`))
+	if decoded["count"] != json.Number("12") {
+		t.Error("test 4 failed", decoded)
+	}
+}
+
+func Test_sessionValues(t *testing.T) {
+	var now = time.Now()
+	var st = &MemSessionStore{sessionValues: sessionValues{value: make(map[interface{}]interface{})}}
+	st.Set("name", "steve")
+	st.Set("count", int64(12))
+	st.Set("admin", true)
+	st.Set("login", now)
+	st.Set("user", &sessionUser{Name: "steve", Roles: []string{"admin"}})
+	if st.GetString("name") != "steve" || st.GetString("count") != "12" || st.GetString("none") != "" {
+		t.Error("test 1 failed")
+	}
+	if st.GetInt("count") != 12 || st.GetInt("name") != 0 || !st.GetBool("admin") || !st.GetTime("login").Equal(now) {
+		t.Error("test 2 failed")
+	}
+	var user sessionUser
+	if err := st.GetObject("user", &user); err != nil || user.Name != "steve" {
+		t.Error("test 3 failed", err)
+	}
+	if st.GetObject("user", user) != errSessionObjectDst || st.GetObject("none", &user) == nil {
+		t.Error("test 4 failed")
+	}
+	var keys []string
+	for _, k := range st.Keys() {
+		keys = append(keys, k.(string))
+	}
+	sort.Strings(keys)
+	if len(keys) != 5 || keys[0] != "admin" || keys[4] != "user" {
+		t.Error("test 5 failed", keys)
+	}
+
+	// the values that are decoded by the json codec
+	var codec = JSONSessionCodec{}
+	data, err := codec.Encode(st.value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := codec.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st = &MemSessionStore{sessionValues: sessionValues{value: decoded}}
+	user = sessionUser{}
+	if st.GetInt("count") != 12 || !st.GetBool("admin") || !st.GetTime("login").Equal(now) {
+		t.Error("test 6 failed")
+	}
+	if err = st.GetObject("user", &user); err != nil || user.Name != "steve" || len(user.Roles) != 1 {
+		t.Error("test 7 failed", err)
+	}
+}
+
+func Test_SessionManager_codec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	app := newServer(".")
+	app.regSessionProvider("file", &fileSessionProvider{})
+	if _, err = app.NewSessionManager("file", &SessionConfig{ProviderConfig: dir, Codec: "xml"}); err == nil {
+		t.Error("test 1 failed")
+	}
+	mgr, err := app.NewSessionManager("file", &SessionConfig{ProviderConfig: dir, Codec: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := mgr.GetSessionStore("sid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("count", 3)
+	store.SessionRelease(nil)
+	data, err := ioutil.ReadFile(mgr.provider.(*fileSessionProvider).filePath("sid1"))
+	if err != nil || string(data) != `{"count":3}` {
+		t.Error("test 2 failed", err, string(data))
+	}
+	if store, err = mgr.GetSessionStore("sid1"); err != nil || store.GetInt("count") != 3 {
+		t.Error("test 3 failed", err)
+	}
+}
@@ -0,0 +1,294 @@
+package wemvc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCookieSize the maximum size of the cookie that the browsers accept, including the cookie name
+const maxCookieSize = 4096
+
+// clientSessionProvider the provider that saves the session in the client cookie.
+// The session cookie is written by the session store when the session is released instead of the session manager
+type clientSessionProvider interface {
+	SessionProvider
+	setCookieConfig(config *SessionConfig)
+	// readCookie read the session store from the cookie value, nil is returned if the value is invalid
+	readCookie(value string) SessionStore
+}
+
+// cookiePayload the data that is encrypted in the session cookie, the values are encoded by the session codec
+type cookiePayload struct {
+	ID       string
+	Values   []byte
+	IssuedAt int64
+}
+
+// cookieSessionProvider the session provider that saves the session values in the session cookie. The values are
+// encoded by the session codec and encrypted by AES-GCM. The ProviderConfig is the comma separated keys in hex or base64, the key
+// should be 16, 24 or 32 bytes. The first key is used to encrypt the cookie, and all the keys are used to decrypt it,
+// so the keys can be rotated by adding a new key to the front
+type cookieSessionProvider struct {
+	providerCodec
+	maxLifetime int64
+	aeads       []cipher.AEAD
+	config      *SessionConfig
+}
+
+// SessionInit parse the keys in the config
+func (prov *cookieSessionProvider) SessionInit(maxLifetime int64, config string) error {
+	var aeads []cipher.AEAD
+	for _, item := range strings.Split(config, ",") {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+		key, err := hex.DecodeString(item)
+		if err != nil {
+			key, err = base64.StdEncoding.DecodeString(item)
+		}
+		if err != nil {
+			return errSessionCookieKey
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return errSessionCookieKey
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return err
+		}
+		aeads = append(aeads, aead)
+	}
+	if len(aeads) == 0 {
+		return errSessionCookieKey
+	}
+	prov.maxLifetime = maxLifetime
+	prov.aeads = aeads
+	return nil
+}
+
+func (prov *cookieSessionProvider) setCookieConfig(config *SessionConfig) {
+	prov.config = config
+}
+
+func (prov *cookieSessionProvider) cookieName() string {
+	if prov.config == nil {
+		return ""
+	}
+	return prov.config.CookieName
+}
+
+// marshal encode the session values and the payload of the session cookie
+func (prov *cookieSessionProvider) marshal(sid string, values map[interface{}]interface{}) ([]byte, error) {
+	data, err := prov.valuesCodec().Encode(values)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(&cookiePayload{ID: sid, Values: data, IssuedAt: time.Now().Unix()}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encode encrypt the session values by the newest key, the cookie name is used as the additional data
+func (prov *cookieSessionProvider) encode(sid string, values map[interface{}]interface{}) (string, error) {
+	data, err := prov.marshal(sid, values)
+	if err != nil {
+		return "", err
+	}
+	var aead = prov.aeads[0]
+	var nonce = make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	var sealed = aead.Seal(nonce, nonce, data, str2Byte(prov.cookieName()))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// encodedSize get the size of the cookie value that the data is encoded to
+func (prov *cookieSessionProvider) encodedSize(dataSize int) int {
+	var aead = prov.aeads[0]
+	return base64.RawURLEncoding.EncodedLen(aead.NonceSize() + dataSize + aead.Overhead())
+}
+
+// decode decrypt the payload and the session values from the cookie value by the keys, the index of the key that
+// decrypts the value is returned. The nil payload is returned if the value is invalid or expired
+func (prov *cookieSessionProvider) decode(value string) (*cookiePayload, map[interface{}]interface{}, int) {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, nil, -1
+	}
+	for i, aead := range prov.aeads {
+		if len(sealed) < aead.NonceSize()+aead.Overhead() {
+			continue
+		}
+		var nonceSize = aead.NonceSize()
+		plain, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], str2Byte(prov.cookieName()))
+		if err != nil {
+			continue
+		}
+		var payload cookiePayload
+		if err = gob.NewDecoder(bytes.NewReader(plain)).Decode(&payload); err != nil {
+			return nil, nil, -1
+		}
+		if prov.maxLifetime > 0 && payload.IssuedAt+prov.maxLifetime < time.Now().Unix() {
+			return nil, nil, -1
+		}
+		values, err := prov.valuesCodec().Decode(payload.Values)
+		if err != nil {
+			return nil, nil, -1
+		}
+		return &payload, values, i
+	}
+	return nil, nil, -1
+}
+
+// readCookie decrypt the session store from the cookie value. The session store is dirty if the cookie is issued
+// before the half of the max lifetime or it is encrypted by an old key, so the cookie is issued again by the newest key
+// when the session is released
+func (prov *cookieSessionProvider) readCookie(value string) SessionStore {
+	payload, values, key := prov.decode(value)
+	if payload == nil {
+		return nil
+	}
+	var renew = key > 0 || prov.maxLifetime > 0 && payload.IssuedAt+prov.maxLifetime/2 < time.Now().Unix()
+	return &CookieSessionStore{sid: payload.ID, sessionValues: sessionValues{value: values, dirty: renew}, provider: prov}
+}
+
+// SessionRead decrypt the session store from the cookie value, the empty session store is returned with the value as
+// the session id if the value can not be decrypted
+func (prov *cookieSessionProvider) SessionRead(sid string) (SessionStore, error) {
+	if store := prov.readCookie(sid); store != nil {
+		return store, nil
+	}
+	return &CookieSessionStore{sid: sid, sessionValues: sessionValues{value: make(map[interface{}]interface{})}, provider: prov}, nil
+}
+
+// SessionExist check the cookie value can be decrypted
+func (prov *cookieSessionProvider) SessionExist(sid string) bool {
+	payload, _, _ := prov.decode(sid)
+	return payload != nil
+}
+
+// SessionRegenerate decrypt the session store from the old cookie value and set the new session id
+func (prov *cookieSessionProvider) SessionRegenerate(oldSid, sid string) (SessionStore, error) {
+	var store = &CookieSessionStore{sid: sid, sessionValues: sessionValues{value: make(map[interface{}]interface{}), dirty: true}, provider: prov}
+	if payload, values, _ := prov.decode(oldSid); payload != nil {
+		store.value = values
+	}
+	return store, nil
+}
+
+// SessionDestroy do nothing, the session cookie is removed by the session manager
+func (prov *cookieSessionProvider) SessionDestroy(sid string) error {
+	return nil
+}
+
+// SessionAll the count of the cookie sessions is unknown, 0 is returned
+func (prov *cookieSessionProvider) SessionAll() int {
+	return 0
+}
+
+// SessionGC do nothing, the expired cookie values are rejected when they are decrypted
+func (prov *cookieSessionProvider) SessionGC() {
+}
+
+// CookieSessionStore the session store that is saved in the encrypted session cookie
+type CookieSessionStore struct {
+	sessionValues
+	sid      string
+	secure   bool
+	provider *cookieSessionProvider
+}
+
+// Set value to cookie session. The errSessionCookieTooLarge is returned and the value is not set if the session cookie
+// would exceed the size limit of the browsers
+func (st *CookieSessionStore) Set(key, value interface{}) error {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	old, exists := st.value[key]
+	st.value[key] = value
+	if err := st.checkSize(); err != nil {
+		if exists {
+			st.value[key] = old
+		} else {
+			delete(st.value, key)
+		}
+		return err
+	}
+	st.dirty = true
+	return nil
+}
+
+// checkSize check the size of the session cookie
+func (st *CookieSessionStore) checkSize() error {
+	data, err := st.provider.marshal(st.sid, st.value)
+	if err != nil {
+		return err
+	}
+	if len(st.provider.cookieName())+1+st.provider.encodedSize(len(data)) > maxCookieSize {
+		return errSessionCookieTooLarge
+	}
+	return nil
+}
+
+// SessionID get the id of the cookie session store
+func (st *CookieSessionStore) SessionID() string {
+	return st.sid
+}
+
+// SessionRelease encrypt the values and write the session cookie, the session cookie that has been set in the
+// response is replaced
+func (st *CookieSessionStore) SessionRelease(w http.ResponseWriter) {
+	if w == nil {
+		return
+	}
+	value, err := st.provider.encode(st.sid, st.snapshot())
+	var config = st.provider.config
+	if err != nil || config == nil || len(config.CookieName)+1+len(value) > maxCookieSize {
+		st.setDirty(true)
+		return
+	}
+	var cookie = &http.Cookie{
+		Name:     config.CookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   config.Domain,
+		HttpOnly: config.HttpOnly,
+		Secure:   st.secure,
+	}
+	if config.CookieLifeTime > 0 {
+		cookie.MaxAge = config.CookieLifeTime
+		cookie.Expires = time.Now().Add(time.Duration(config.CookieLifeTime) * time.Second)
+	}
+	replaceCookie(w, cookie)
+}
+
+// replaceCookie set the cookie and remove the cookies of the same name that have been set in the response
+func replaceCookie(w http.ResponseWriter, cookie *http.Cookie) {
+	var header = w.Header()
+	var cookies = header["Set-Cookie"]
+	var kept = cookies[:0]
+	var prefix = strAdd(cookie.Name, "=")
+	for _, c := range cookies {
+		if !strings.HasPrefix(c, prefix) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		header.Del("Set-Cookie")
+	} else {
+		header["Set-Cookie"] = kept
+	}
+	http.SetCookie(w, cookie)
+}
@@ -0,0 +1,106 @@
+package wemvc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testCookieKey1 = "000102030405060708090a0b0c0d0e0f"
+	testCookieKey2 = "101112131415161718191a1b1c1d1e1f"
+)
+
+func sessionCookie(t *testing.T, w *httptest.ResponseRecorder) *http.Cookie {
+	var cookies = w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatal("invalid session cookies", w.Header()["Set-Cookie"])
+	}
+	return cookies[0]
+}
+
+func Test_cookieSessionProvider(t *testing.T) {
+	if _, err := newServer(".").NewSessionManager("memory", &SessionConfig{}); err == nil {
+		t.Error("test 1 failed")
+	}
+	var prov = &cookieSessionProvider{}
+	if prov.SessionInit(60, "invalid") != errSessionCookieKey {
+		t.Error("test 2 failed")
+	}
+
+	app := newServer(".")
+	app.regSessionProvider("cookie", &cookieSessionProvider{})
+	mgr, err := app.NewSessionManager("cookie", &SessionConfig{CookieName: "sid", HttpOnly: true, MaxLifetime: 3600, ProviderConfig: testCookieKey1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	store, err := mgr.SessionStart(w, httptest.NewRequest("GET", "/", nil))
+	if err != nil || len(w.Header()["Set-Cookie"]) != 0 {
+		t.Fatal("test 3 failed", err)
+	}
+	var id = store.SessionID()
+	store.Set("name", "steve")
+	store.SessionRelease(w)
+	var cookie = sessionCookie(t, w)
+	if strings.Contains(cookie.Value, "steve") || !cookie.HttpOnly {
+		t.Error("test 4 failed", cookie)
+	}
+
+	// read the session by the rotated keys
+	app = newServer(".")
+	app.regSessionProvider("cookie", &cookieSessionProvider{})
+	mgr, err = app.NewSessionManager("cookie", &SessionConfig{CookieName: "sid", MaxLifetime: 3600, ProviderConfig: testCookieKey2 + "," + testCookieKey1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	store, err = mgr.SessionStart(w, req)
+	if err != nil || store.SessionID() != id || store.Get("name") != "steve" || !store.(dirtySessionStore).Dirty() {
+		t.Fatal("test 5 failed", err)
+	}
+	// the cookie of the old key is encrypted by the newest key when the session is released
+	store.SessionRelease(w)
+	if payload, _, key := mgr.provider.(*cookieSessionProvider).decode(sessionCookie(t, w).Value); payload == nil || key != 0 {
+		t.Error("test 6 failed")
+	}
+
+	// the tampered cookie is rejected
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: cookie.Value[:len(cookie.Value)-2] + "AA"})
+	store, _ = mgr.SessionStart(httptest.NewRecorder(), req)
+	if store.SessionID() == id || store.Get("name") != nil {
+		t.Error("test 7 failed")
+	}
+
+	// regenerate the session id and keep the values
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	store = mgr.SessionRegenerateID(w, req)
+	if store.SessionID() == id || store.Get("name") != "steve" {
+		t.Error("test 8 failed")
+	}
+	store.Set("count", 1)
+	store.SessionRelease(w)
+	var regenerated = sessionCookie(t, w)
+	if !mgr.provider.SessionExist(regenerated.Value) {
+		t.Error("test 9 failed")
+	}
+
+	if err = store.Set("large", strings.Repeat("x", maxCookieSize)); err != errSessionCookieTooLarge || store.Get("large") != nil {
+		t.Error("test 10 failed", err)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(regenerated)
+	w = httptest.NewRecorder()
+	store.SessionRelease(w)
+	mgr.SessionDestroy(w, req)
+	if cookie = sessionCookie(t, w); cookie.MaxAge != -1 {
+		t.Error("test 11 failed", cookie)
+	}
+}
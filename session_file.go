@@ -0,0 +1,330 @@
+package wemvc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sessionFileExt = ".session"
+	sessionLockExt = ".lock"
+)
+
+// fileSessionProvider the session provider that saves the session stores as files in the directory that is set by
+// the ProviderConfig. Each session is saved in the file named by the session id, the file is replaced atomically when
+// the session is released, and the expired files are removed by the modification time
+type fileSessionProvider struct {
+	providerCodec
+	lock        sync.RWMutex
+	maxLifetime int64
+	savePath    string
+}
+
+// SessionInit init the file session provider, the directory of the session files is created if it does not exist
+func (prov *fileSessionProvider) SessionInit(maxLifetime int64, savePath string) error {
+	if len(savePath) == 0 {
+		savePath = filepath.Join(os.TempDir(), "wemvc_sessions")
+	}
+	if err := os.MkdirAll(savePath, 0700); err != nil {
+		return err
+	}
+	prov.maxLifetime = maxLifetime
+	prov.savePath = savePath
+	return nil
+}
+
+// validSessionID check the session id can be used as the file name
+func validSessionID(sid string) bool {
+	if len(sid) == 0 || len(sid) > 128 {
+		return false
+	}
+	for i := 0; i < len(sid); i++ {
+		c := sid[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func (prov *fileSessionProvider) filePath(sid string) string {
+	return filepath.Join(prov.savePath, strAdd(sid, sessionFileExt))
+}
+
+func (prov *fileSessionProvider) lockPath(sid string) string {
+	return filepath.Join(prov.savePath, strAdd(sid, sessionLockExt))
+}
+
+// lockSession lock the session by the lock file of it, the returned function releases the lock. The lock file may be
+// removed by the gc while it is waited for, so the lock is acquired again if the locked file is not the lock file anymore
+func (prov *fileSessionProvider) lockSession(sid string) (func(), error) {
+	var path = prov.lockPath(sid)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err = lockFile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		locked, err1 := f.Stat()
+		current, err2 := os.Stat(path)
+		if err1 == nil && err2 == nil && os.SameFile(locked, current) {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// readFile read the session values from the session file, the empty values are returned if the file does not exist
+func (prov *fileSessionProvider) readFile(sid string) (map[interface{}]interface{}, error) {
+	data, err := ioutil.ReadFile(prov.filePath(sid))
+	if os.IsNotExist(err) || (err == nil && len(data) == 0) {
+		return make(map[interface{}]interface{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prov.valuesCodec().Decode(data)
+}
+
+// writeFile write the session values to a temporary file and rename it to the session file
+func (prov *fileSessionProvider) writeFile(sid string, values map[interface{}]interface{}) error {
+	data, err := prov.valuesCodec().Encode(values)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(prov.savePath, strAdd(sid, ".tmp"))
+	if err != nil {
+		return err
+	}
+	var tmpName = f.Name()
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpName, prov.filePath(sid))
+	}
+	if err != nil {
+		os.Remove(tmpName)
+	}
+	return err
+}
+
+// SessionRead read the session store from the session file, the empty session file is created if it does not exist.
+// The modification time of the file is updated to keep the session alive
+func (prov *fileSessionProvider) SessionRead(sid string) (SessionStore, error) {
+	if !validSessionID(sid) {
+		return nil, errInvalidSessionID
+	}
+	prov.lock.RLock()
+	defer prov.lock.RUnlock()
+	unlock, err := prov.lockSession(sid)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	values, err := prov.readFile(sid)
+	if err != nil {
+		return nil, err
+	}
+	var path = prov.filePath(sid)
+	if _, err = os.Stat(path); os.IsNotExist(err) {
+		if err = prov.writeFile(sid, values); err != nil {
+			return nil, err
+		}
+	} else {
+		var now = time.Now()
+		os.Chtimes(path, now, now)
+	}
+	return &FileSessionStore{sid: sid, sessionValues: sessionValues{value: values}, provider: prov}, nil
+}
+
+// SessionExist check the session file exists
+func (prov *fileSessionProvider) SessionExist(sid string) bool {
+	if !validSessionID(sid) {
+		return false
+	}
+	_, err := os.Stat(prov.filePath(sid))
+	return err == nil
+}
+
+// SessionRegenerate rename the session file of the old session id to the new session id
+func (prov *fileSessionProvider) SessionRegenerate(oldSid, sid string) (SessionStore, error) {
+	if !validSessionID(oldSid) || !validSessionID(sid) {
+		return nil, errInvalidSessionID
+	}
+	prov.lock.RLock()
+	unlock, err := prov.lockSession(oldSid)
+	if err != nil {
+		prov.lock.RUnlock()
+		return nil, err
+	}
+	var oldPath = prov.filePath(oldSid)
+	if _, err = os.Stat(oldPath); err == nil {
+		err = os.Rename(oldPath, prov.filePath(sid))
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	unlock()
+	prov.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return prov.SessionRead(sid)
+}
+
+// SessionDestroy remove the session file
+func (prov *fileSessionProvider) SessionDestroy(sid string) error {
+	if !validSessionID(sid) {
+		return nil
+	}
+	prov.lock.RLock()
+	defer prov.lock.RUnlock()
+	unlock, err := prov.lockSession(sid)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(prov.filePath(sid))
+	unlock()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SessionGC remove the session files that are not modified in the max lifetime.
+// The lock files are removed with the session files, or when the session files do not exist.
+// The expired sessions are removed one by one, so the other sessions are not blocked while waiting for the lock of them
+func (prov *fileSessionProvider) SessionGC() {
+	var expired = time.Now().Add(-time.Duration(prov.maxLifetime) * time.Second)
+	for _, sid := range prov.expiredSessions(expired) {
+		prov.lock.RLock()
+		prov.removeExpired(sid, expired)
+		prov.lock.RUnlock()
+	}
+}
+
+// expiredSessions get the ids of the session files and the lock files that are not modified since the expired time,
+// and remove the temporary files that are expired
+func (prov *fileSessionProvider) expiredSessions(expired time.Time) []string {
+	prov.lock.Lock()
+	defer prov.lock.Unlock()
+	files, err := ioutil.ReadDir(prov.savePath)
+	if err != nil {
+		return nil
+	}
+	var sids []string
+	var found = make(map[string]bool)
+	for _, f := range files {
+		if f.IsDir() || !f.ModTime().Before(expired) {
+			continue
+		}
+		var name, sid = f.Name(), ""
+		switch {
+		case strings.HasSuffix(name, sessionFileExt):
+			sid = strings.TrimSuffix(name, sessionFileExt)
+		case strings.HasSuffix(name, sessionLockExt):
+			sid = strings.TrimSuffix(name, sessionLockExt)
+		case strings.Contains(name, ".tmp"):
+			os.Remove(filepath.Join(prov.savePath, name))
+		}
+		if len(sid) > 0 && !found[sid] {
+			found[sid] = true
+			sids = append(sids, sid)
+		}
+	}
+	return sids
+}
+
+// removeExpired remove the session file and the lock file of the session under the lock of it, if the session file
+// is still expired or it does not exist. The processes that wait for the removed lock file lock the new one
+func (prov *fileSessionProvider) removeExpired(sid string, expired time.Time) {
+	if !validSessionID(sid) {
+		return
+	}
+	unlock, err := prov.lockSession(sid)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	var path = prov.filePath(sid)
+	stat, err := os.Stat(path)
+	if err == nil && !stat.ModTime().Before(expired) {
+		return
+	}
+	if err == nil {
+		os.Remove(path)
+	}
+	os.Remove(prov.lockPath(sid))
+}
+
+// SessionAll get the count of the session files
+func (prov *fileSessionProvider) SessionAll() int {
+	files, err := ioutil.ReadDir(prov.savePath)
+	if err != nil {
+		return 0
+	}
+	var count = 0
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), sessionFileExt) {
+			count++
+		}
+	}
+	return count
+}
+
+// FileSessionStore the session store that is saved as a file by the file session provider
+type FileSessionStore struct {
+	sessionValues
+	sid      string
+	provider *fileSessionProvider
+}
+
+// Set value to file session. The error is returned and the value is not set if it can not be encoded by the session
+// codec, so the other values of the session can still be saved
+func (st *FileSessionStore) Set(key, value interface{}) error {
+	if _, err := st.provider.valuesCodec().Encode(map[interface{}]interface{}{key: value}); err != nil {
+		return err
+	}
+	return st.sessionValues.Set(key, value)
+}
+
+// SessionID get the id of the file session store
+func (st *FileSessionStore) SessionID() string {
+	return st.sid
+}
+
+// SessionRelease save the values to the session file. The session file is replaced atomically under the lock of the
+// session, so the concurrent requests of the same session do not corrupt the file. The error is reported by Err
+func (st *FileSessionStore) SessionRelease(w http.ResponseWriter) {
+	st.setErr(st.save())
+}
+
+func (st *FileSessionStore) save() error {
+	var prov = st.provider
+	prov.lock.RLock()
+	defer prov.lock.RUnlock()
+	unlock, err := prov.lockSession(st.sid)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err = prov.writeFile(st.sid, st.snapshot()); err != nil {
+		st.setDirty(true)
+	}
+	return err
+}
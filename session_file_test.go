@@ -0,0 +1,190 @@
+package wemvc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_fileSessionProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var prov = &fileSessionProvider{}
+	if err = prov.SessionInit(60, dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = prov.SessionRead("../config"); err != errInvalidSessionID {
+		t.Error("test 1 failed", err)
+	}
+	store, err := prov.SessionRead("sid1")
+	if err != nil || !prov.SessionExist("sid1") || prov.SessionAll() != 1 {
+		t.Fatal("test 2 failed", err)
+	}
+	store.Set("name", "steve")
+	store.Set("count", 12)
+	store.SessionRelease(nil)
+
+	store, err = (&fileSessionProvider{savePath: dir, maxLifetime: 60}).SessionRead("sid1")
+	if err != nil || store.Get("name") != "steve" || store.Get("count") != 12 {
+		t.Error("test 3 failed", err, store)
+	}
+
+	store, err = prov.SessionRegenerate("sid1", "sid2")
+	if err != nil || prov.SessionExist("sid1") || store.SessionID() != "sid2" || store.Get("name") != "steve" {
+		t.Error("test 4 failed", err)
+	}
+
+	// the concurrent releases of the same session replace the file atomically
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := prov.SessionRead("sid2")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.Set("count", i)
+			s.SessionRelease(nil)
+		}(i)
+	}
+	wg.Wait()
+	if store, err = prov.SessionRead("sid2"); err != nil || store.Get("name") != "steve" {
+		t.Error("test 5 failed", err)
+	}
+
+	// the value that can not be encoded is rejected, and the other values are still saved
+	type unregistered struct{ X int }
+	if err = store.Set("obj", unregistered{1}); err == nil || store.Get("obj") != nil {
+		t.Error("test 6 failed", err)
+	}
+	store.Set("name", "bob")
+	store.SessionRelease(nil)
+	if store, err = prov.SessionRead("sid2"); err != nil || store.Get("name") != "bob" {
+		t.Error("test 7 failed", err)
+	}
+
+	prov.SessionRead("sid3")
+	var old = time.Now().Add(-2 * time.Minute)
+	os.Chtimes(filepath.Join(dir, "sid3"+sessionFileExt), old, old)
+	prov.SessionGC()
+	if prov.SessionExist("sid3") || !prov.SessionExist("sid2") {
+		t.Error("test 8 failed")
+	}
+	if prov.SessionDestroy("sid2") != nil || prov.SessionExist("sid2") || prov.SessionAll() != 0 {
+		t.Error("test 9 failed")
+	}
+
+	// the error of saving the session is reported by Err
+	store, _ = prov.SessionRead("sid4")
+	os.RemoveAll(dir)
+	store.Set("name", "steve")
+	store.SessionRelease(nil)
+	if store.(errSessionStore).Err() == nil || !store.(dirtySessionStore).Dirty() {
+		t.Error("test 10 failed")
+	}
+	os.MkdirAll(dir, 0755)
+	store.SessionRelease(nil)
+	if store.(errSessionStore).Err() != nil || !prov.SessionExist("sid4") {
+		t.Error("test 11 failed", store.(errSessionStore).Err())
+	}
+}
+
+func Test_fileSessionProvider_lockSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var prov = &fileSessionProvider{}
+	if err = prov.SessionInit(60, dir); err != nil {
+		t.Fatal(err)
+	}
+	unlockA, err := prov.lockSession("sid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var acquired = make(chan func(), 2)
+	var lock = func() {
+		unlock, err := prov.lockSession("sid1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		acquired <- unlock
+	}
+	go lock()
+	time.Sleep(50 * time.Millisecond)
+	// the lock file is removed while it is waited for, like the gc does
+	os.Remove(prov.lockPath("sid1"))
+	unlockA()
+	var unlockB = <-acquired
+	go lock()
+	select {
+	case <-acquired:
+		t.Error("test 1 failed: the session is locked twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlockB()
+	select {
+	case unlock := <-acquired:
+		unlock()
+	case <-time.After(5 * time.Second):
+		t.Error("test 2 failed")
+	}
+}
+
+func Test_fileSessionProvider_SessionGC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var prov = &fileSessionProvider{}
+	if err = prov.SessionInit(60, dir); err != nil {
+		t.Fatal(err)
+	}
+	prov.SessionRead("sid1")
+	var old = time.Now().Add(-2 * time.Minute)
+	os.Chtimes(filepath.Join(dir, "sid1"+sessionFileExt), old, old)
+	unlock, err := prov.lockSession("sid1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done = make(chan bool)
+	go func() {
+		prov.SessionGC()
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	// the other sessions are not blocked while the gc waits for the lock of the expired session
+	var read = make(chan error, 1)
+	go func() {
+		_, err := prov.SessionRead("sid2")
+		read <- err
+	}()
+	select {
+	case err = <-read:
+		if err != nil {
+			t.Error("test 1 failed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("test 1 failed: the session is blocked by the gc")
+	}
+	unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("test 2 failed")
+	}
+	if prov.SessionExist("sid1") || !prov.SessionExist("sid2") {
+		t.Error("test 3 failed")
+	}
+}
@@ -0,0 +1,225 @@
+package wemvc
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSessionTable = "wemvc_sessions"
+
+// sqlSessionProvider the session provider that saves the session stores in the database. The ProviderConfig is the
+// name of the connection string in the config file, and the table name can be set after it like 'sessionDb,sessions'.
+// The table is created if it does not exist, and the values are encoded by the session codec
+type sqlSessionProvider struct {
+	providerCodec
+	conf        Configuration
+	db          *sql.DB
+	table       string
+	postgres    bool
+	maxLifetime int64
+}
+
+// validTableName check the table name can be used in the sql statements
+func validTableName(name string) bool {
+	if len(name) == 0 || len(name) > 64 || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// SessionInit open the database by the connection string and create the session table
+func (prov *sqlSessionProvider) SessionInit(maxLifetime int64, config string) error {
+	var connName, table = config, defaultSessionTable
+	if index := strings.IndexByte(config, ','); index >= 0 {
+		connName, table = strings.TrimSpace(config[:index]), strings.TrimSpace(config[index+1:])
+	}
+	if len(connName) == 0 || !validTableName(table) {
+		return errSessionSQLConfig(config)
+	}
+	if prov.conf == nil {
+		return errSessionSQLConn(connName)
+	}
+	driverName, _ := prov.conf.GetConnConfig(connName)
+	db, err := prov.conf.GetConn(connName)
+	if err != nil {
+		return err
+	}
+	prov.db = db
+	prov.table = table
+	prov.postgres = driverName == "postgres" || driverName == "pgx"
+	prov.maxLifetime = maxLifetime
+	var dataType = "BLOB"
+	if prov.postgres {
+		dataType = "BYTEA"
+	}
+	_, err = db.Exec(strAdd("CREATE TABLE IF NOT EXISTS ", table,
+		" (session_id VARCHAR(128) NOT NULL PRIMARY KEY, session_data ", dataType, ", session_expiry BIGINT NOT NULL)"))
+	return err
+}
+
+// query replace the '?' placeholders by '$1', '$2'... for the postgres drivers
+func (prov *sqlSessionProvider) query(parts ...string) string {
+	var query = strAdd(parts...)
+	if !prov.postgres {
+		return query
+	}
+	var buf strings.Builder
+	var n = 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			buf.WriteString(strAdd("$", strconv.Itoa(n)))
+			continue
+		}
+		buf.WriteByte(query[i])
+	}
+	return buf.String()
+}
+
+func (prov *sqlSessionProvider) expiry() int64 {
+	return time.Now().Unix() + prov.maxLifetime
+}
+
+// SessionRead read the session store from the session table, the session row is created if it does not exist or it
+// is expired. The expiry of the session is extended to keep the session alive
+func (prov *sqlSessionProvider) SessionRead(sid string) (SessionStore, error) {
+	if !validSessionID(sid) {
+		return nil, errInvalidSessionID
+	}
+	var data []byte
+	var expiry int64
+	err := prov.db.QueryRow(prov.query("SELECT session_data, session_expiry FROM ", prov.table, " WHERE session_id = ?"), sid).Scan(&data, &expiry)
+	switch {
+	case err == sql.ErrNoRows:
+		data, err = prov.valuesCodec().Encode(nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = prov.db.Exec(prov.query("INSERT INTO ", prov.table, " (session_id, session_data, session_expiry) VALUES (?, ?, ?)"), sid, data, prov.expiry())
+	case err != nil:
+	case expiry < time.Now().Unix():
+		data, err = prov.valuesCodec().Encode(nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = prov.db.Exec(prov.query("UPDATE ", prov.table, " SET session_data = ?, session_expiry = ? WHERE session_id = ?"), data, prov.expiry(), sid)
+	default:
+		_, err = prov.db.Exec(prov.query("UPDATE ", prov.table, " SET session_expiry = ? WHERE session_id = ?"), prov.expiry(), sid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err := prov.valuesCodec().Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return &SQLSessionStore{sid: sid, sessionValues: sessionValues{value: values}, provider: prov}, nil
+}
+
+// SessionExist check the session row exists and it is not expired
+func (prov *sqlSessionProvider) SessionExist(sid string) bool {
+	if !validSessionID(sid) {
+		return false
+	}
+	var count int
+	err := prov.db.QueryRow(prov.query("SELECT COUNT(*) FROM ", prov.table, " WHERE session_id = ? AND session_expiry >= ?"), sid, time.Now().Unix()).Scan(&count)
+	return err == nil && count > 0
+}
+
+// SessionRegenerate change the session id of the session row
+func (prov *sqlSessionProvider) SessionRegenerate(oldSid, sid string) (SessionStore, error) {
+	if !validSessionID(oldSid) || !validSessionID(sid) {
+		return nil, errInvalidSessionID
+	}
+	_, err := prov.db.Exec(prov.query("UPDATE ", prov.table, " SET session_id = ? WHERE session_id = ?"), sid, oldSid)
+	if err != nil {
+		return nil, err
+	}
+	return prov.SessionRead(sid)
+}
+
+// SessionDestroy delete the session row
+func (prov *sqlSessionProvider) SessionDestroy(sid string) error {
+	_, err := prov.db.Exec(prov.query("DELETE FROM ", prov.table, " WHERE session_id = ?"), sid)
+	return err
+}
+
+// SessionGC delete the expired session rows
+func (prov *sqlSessionProvider) SessionGC() {
+	prov.db.Exec(prov.query("DELETE FROM ", prov.table, " WHERE session_expiry < ?"), time.Now().Unix())
+}
+
+// SessionAll get the count of the session rows that are not expired
+func (prov *sqlSessionProvider) SessionAll() int {
+	var count int
+	err := prov.db.QueryRow(prov.query("SELECT COUNT(*) FROM ", prov.table, " WHERE session_expiry >= ?"), time.Now().Unix()).Scan(&count)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// SQLSessionStore the session store that is saved in the database by the sql session provider
+type SQLSessionStore struct {
+	sessionValues
+	sid      string
+	provider *sqlSessionProvider
+}
+
+// Set value to sql session. The error is returned and the value is not set if it can not be encoded by the session
+// codec, so the other values of the session can still be saved
+func (st *SQLSessionStore) Set(key, value interface{}) error {
+	if _, err := st.provider.valuesCodec().Encode(map[interface{}]interface{}{key: value}); err != nil {
+		return err
+	}
+	return st.sessionValues.Set(key, value)
+}
+
+// SessionID get the id of the sql session store
+func (st *SQLSessionStore) SessionID() string {
+	return st.sid
+}
+
+// SessionRelease save the values to the session row and extend the expiry of it. The error is reported by Err
+func (st *SQLSessionStore) SessionRelease(w http.ResponseWriter) {
+	st.setErr(st.save())
+}
+
+func (st *SQLSessionStore) save() error {
+	err := st.write(st.snapshot())
+	if err != nil {
+		st.setDirty(true)
+	}
+	return err
+}
+
+func (st *SQLSessionStore) write(values map[interface{}]interface{}) error {
+	var prov = st.provider
+	data, err := prov.valuesCodec().Encode(values)
+	if err != nil {
+		return err
+	}
+	result, err := prov.db.Exec(prov.query("UPDATE ", prov.table, " SET session_data = ?, session_expiry = ? WHERE session_id = ?"), data, prov.expiry(), st.sid)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		return nil
+	}
+	// the session row is removed by the gc
+	_, err = prov.db.Exec(prov.query("INSERT INTO ", prov.table, " (session_id, session_data, session_expiry) VALUES (?, ?, ?)"), st.sid, data, prov.expiry())
+	if err != nil && prov.SessionExist(st.sid) {
+		// some drivers report 0 affected rows if the row is not changed
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,266 @@
+package wemvc
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSessionRow the row of the session table in the test driver
+type testSessionRow struct {
+	data   []byte
+	expiry int64
+}
+
+// testSessionDriver the database/sql driver that understands the statements of the sql session provider
+type testSessionDriver struct {
+	lock    sync.Mutex
+	created bool
+	rows    map[string]*testSessionRow
+}
+
+func (d *testSessionDriver) Open(name string) (driver.Conn, error) {
+	return &testSessionConn{d}, nil
+}
+
+type testSessionConn struct {
+	d *testSessionDriver
+}
+
+func (c *testSessionConn) Prepare(query string) (driver.Stmt, error) {
+	return &testSessionStmt{d: c.d, query: query}, nil
+}
+
+func (c *testSessionConn) Close() error {
+	return nil
+}
+
+func (c *testSessionConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type testSessionStmt struct {
+	d     *testSessionDriver
+	query string
+}
+
+func (s *testSessionStmt) Close() error {
+	return nil
+}
+
+func (s *testSessionStmt) NumInput() int {
+	return strings.Count(s.query, "?")
+}
+
+type testSessionResult int64
+
+func (r testSessionResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+func (r testSessionResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+func (s *testSessionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	var d = s.d
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var q = s.query
+	switch {
+	case strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS test_sessions "):
+		d.created = true
+		return testSessionResult(0), nil
+	case !d.created:
+		return nil, errors.New("table not found")
+	case strings.HasPrefix(q, "INSERT INTO"):
+		var sid = args[0].(string)
+		if _, ok := d.rows[sid]; ok {
+			return nil, errors.New("duplicate key")
+		}
+		d.rows[sid] = &testSessionRow{data: args[1].([]byte), expiry: args[2].(int64)}
+		return testSessionResult(1), nil
+	case strings.Contains(q, "SET session_data = ?, session_expiry = ?"):
+		if row, ok := d.rows[args[2].(string)]; ok {
+			row.data, row.expiry = args[0].([]byte), args[1].(int64)
+			return testSessionResult(1), nil
+		}
+	case strings.Contains(q, "SET session_expiry = ?"):
+		if row, ok := d.rows[args[1].(string)]; ok {
+			row.expiry = args[0].(int64)
+			return testSessionResult(1), nil
+		}
+	case strings.Contains(q, "SET session_id = ?"):
+		if row, ok := d.rows[args[1].(string)]; ok {
+			delete(d.rows, args[1].(string))
+			d.rows[args[0].(string)] = row
+			return testSessionResult(1), nil
+		}
+	case strings.HasPrefix(q, "DELETE") && strings.Contains(q, "session_id = ?"):
+		if _, ok := d.rows[args[0].(string)]; ok {
+			delete(d.rows, args[0].(string))
+			return testSessionResult(1), nil
+		}
+	case strings.HasPrefix(q, "DELETE") && strings.Contains(q, "session_expiry < ?"):
+		var n int64
+		for sid, row := range d.rows {
+			if row.expiry < args[0].(int64) {
+				delete(d.rows, sid)
+				n++
+			}
+		}
+		return testSessionResult(n), nil
+	default:
+		return nil, errors.New(strAdd("unknown statement: ", q))
+	}
+	return testSessionResult(0), nil
+}
+
+func (s *testSessionStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var d = s.d
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var q = s.query
+	switch {
+	case strings.HasPrefix(q, "SELECT session_data, session_expiry"):
+		var rows = &testSessionRows{columns: []string{"session_data", "session_expiry"}}
+		if row, ok := d.rows[args[0].(string)]; ok {
+			rows.values = append(rows.values, []driver.Value{row.data, row.expiry})
+		}
+		return rows, nil
+	case strings.HasPrefix(q, "SELECT COUNT(*)"):
+		var count int64
+		for sid, row := range d.rows {
+			if strings.Contains(q, "session_id = ?") {
+				if sid == args[0].(string) && row.expiry >= args[1].(int64) {
+					count++
+				}
+			} else if row.expiry >= args[0].(int64) {
+				count++
+			}
+		}
+		return &testSessionRows{columns: []string{"count"}, values: [][]driver.Value{{count}}}, nil
+	}
+	return nil, errors.New(strAdd("unknown query: ", q))
+}
+
+type testSessionRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *testSessionRows) Columns() []string {
+	return r.columns
+}
+
+func (r *testSessionRows) Close() error {
+	return nil
+}
+
+func (r *testSessionRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var testSessionDB = &testSessionDriver{rows: make(map[string]*testSessionRow)}
+
+func init() {
+	sql.Register("wemvc_session_test", testSessionDB)
+}
+
+func Test_sqlSessionProvider(t *testing.T) {
+	var conf = &config{connMap: map[string]*connSetting{
+		"sessionDb": {typeName: "wemvc_session_test", connString: "sessions"},
+	}}
+	if err := (&sqlSessionProvider{conf: conf}).SessionInit(60, "sessionDb,test-sessions"); err == nil {
+		t.Error("test 1 failed")
+	}
+	if err := (&sqlSessionProvider{conf: conf}).SessionInit(60, "unknownDb"); err == nil {
+		t.Error("test 2 failed")
+	}
+	var prov = &sqlSessionProvider{conf: conf}
+	if err := prov.SessionInit(60, "sessionDb, test_sessions"); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := prov.SessionRead("sid1")
+	if err != nil || !prov.SessionExist("sid1") || prov.SessionAll() != 1 {
+		t.Fatal("test 3 failed", err)
+	}
+	store.Set("name", "steve")
+	store.Set("count", 12)
+	store.SessionRelease(nil)
+	store, err = prov.SessionRead("sid1")
+	if err != nil || store.Get("name") != "steve" || store.Get("count") != 12 {
+		t.Error("test 4 failed", err)
+	}
+	type unregistered struct{ X int }
+	if err = store.Set("obj", unregistered{1}); err == nil || store.Get("obj") != nil {
+		t.Error("test 5 failed", err)
+	}
+
+	store, err = prov.SessionRegenerate("sid1", "sid2")
+	if err != nil || prov.SessionExist("sid1") || store.SessionID() != "sid2" || store.Get("name") != "steve" {
+		t.Error("test 6 failed", err)
+	}
+
+	// the released session is saved again after it is destroyed
+	prov.SessionDestroy("sid2")
+	if prov.SessionExist("sid2") {
+		t.Error("test 7 failed")
+	}
+	store.SessionRelease(nil)
+	if !prov.SessionExist("sid2") {
+		t.Error("test 8 failed")
+	}
+
+	// the expired sessions are not read and removed by the gc
+	testSessionDB.lock.Lock()
+	testSessionDB.rows["sid2"].expiry = time.Now().Unix() - 1
+	testSessionDB.lock.Unlock()
+	if prov.SessionExist("sid2") || prov.SessionAll() != 0 {
+		t.Error("test 9 failed")
+	}
+	prov.SessionGC()
+	testSessionDB.lock.Lock()
+	var count = len(testSessionDB.rows)
+	testSessionDB.lock.Unlock()
+	if count != 0 {
+		t.Error("test 10 failed", count)
+	}
+	if _, err = prov.SessionRead("../sid"); err != errInvalidSessionID {
+		t.Error("test 11 failed", err)
+	}
+
+	// the error of saving the session is reported by Err
+	store, _ = prov.SessionRead("sid3")
+	store.Set("name", "steve")
+	testSessionDB.lock.Lock()
+	testSessionDB.created = false
+	testSessionDB.lock.Unlock()
+	store.SessionRelease(nil)
+	testSessionDB.lock.Lock()
+	testSessionDB.created = true
+	testSessionDB.lock.Unlock()
+	if store.(errSessionStore).Err() == nil || !store.(dirtySessionStore).Dirty() {
+		t.Error("test 12 failed")
+	}
+	store.SessionRelease(nil)
+	if store.(errSessionStore).Err() != nil {
+		t.Error("test 13 failed", store.(errSessionStore).Err())
+	}
+
+	var pg = &sqlSessionProvider{postgres: true}
+	if q := pg.query("UPDATE t SET a = ?, b = ? WHERE c = ?"); q != "UPDATE t SET a = $1, b = $2 WHERE c = $3" {
+		t.Error("test 14 failed", q)
+	}
+}
@@ -0,0 +1,81 @@
+package wemvc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// URLPolicy the policy of the request url that is not canonical. The canonical url has no trailing slash
+// (except the url of the static directory) and uses the same letter case as the route or the static path setting
+type URLPolicy uint8
+
+const (
+	// PolicyLenient serve the request url as the canonical url
+	PolicyLenient URLPolicy = iota
+	// PolicyStrict the request url that is not canonical is not found
+	PolicyStrict
+	// PolicyRedirect redirect the request to the canonical url.
+	// The GET and HEAD requests are redirected with 301, the others are redirected with 308 to keep the method and body
+	PolicyRedirect
+)
+
+// String get the name of the url policy
+func (p URLPolicy) String() string {
+	switch p {
+	case PolicyLenient:
+		return "lenient"
+	case PolicyStrict:
+		return "strict"
+	case PolicyRedirect:
+		return "redirect"
+	}
+	return "unknown"
+}
+
+func (p URLPolicy) valid() bool {
+	return p <= PolicyRedirect
+}
+
+// parseURLPolicy parse the url policy setting in config file like 'strict', 'redirect' or 'lenient'
+func parseURLPolicy(s string) (URLPolicy, error) {
+	switch strings.ToLower(s) {
+	case "lenient":
+		return PolicyLenient, nil
+	case "strict":
+		return PolicyStrict, nil
+	case "redirect":
+		return PolicyRedirect, nil
+	}
+	return PolicyLenient, errInvalidURLPolicy(s)
+}
+
+// redirectCanonical create the result that redirect the request to the canonical url path, the query string is kept
+func redirectCanonical(req *http.Request, canonicalPath string) *RedirectResult {
+	var statusCode = http.StatusPermanentRedirect
+	if req.Method == "GET" || req.Method == "HEAD" {
+		statusCode = http.StatusMovedPermanently
+	}
+	var redirectURL = canonicalPath
+	if len(req.URL.RawQuery) > 0 {
+		redirectURL = strAdd(redirectURL, "?", req.URL.RawQuery)
+	}
+	return &RedirectResult{RedirectURL: redirectURL, StatusCode: statusCode}
+}
+
+// cleanURLPath get the url path that is joined by the url parts. The url path is returned directly if it is already clean
+func cleanURLPath(urlPath string, parts []string) string {
+	if len(parts) == 0 {
+		return "/"
+	}
+	var i = 0
+	for _, p := range parts {
+		if i >= len(urlPath) || urlPath[i] != '/' || !strings.HasPrefix(urlPath[i+1:], p) {
+			return strAdd("/", strings.Join(parts, "/"))
+		}
+		i += len(p) + 1
+	}
+	if i != len(urlPath) {
+		return strAdd("/", strings.Join(parts, "/"))
+	}
+	return urlPath
+}
@@ -0,0 +1,343 @@
+package wemvc
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var emailReg, _ = regexp.Compile("^[^@\\s]+@[^@\\s]+\\.[^@\\s]+$")
+
+// fieldRule the validation rules of the model field, which are set by the 'validate' tag like:
+// `validate:"required,min=1,max=100,len=6,email,oneof=red green blue,pattern=^[a-z]+$"`.
+// The 'pattern' rule should be the last one because the regular expression may contain ','
+type fieldRule struct {
+	index    int
+	name     string
+	required bool
+	min      *float64
+	max      *float64
+	length   int
+	pattern  *regexp.Regexp
+	email    bool
+	oneOf    []string
+	nested   bool
+}
+
+var modelRules = struct {
+	sync.RWMutex
+	rules map[reflect.Type][]*fieldRule
+}{rules: make(map[reflect.Type][]*fieldRule)}
+
+// getModelRules get the validation rules of the model type, the rules are cached by the type
+func getModelRules(t reflect.Type) []*fieldRule {
+	modelRules.RLock()
+	rules, ok := modelRules.rules[t]
+	modelRules.RUnlock()
+	if ok {
+		return rules
+	}
+	rules = parseModelRules(t)
+	modelRules.Lock()
+	modelRules.rules[t] = rules
+	modelRules.Unlock()
+	return rules
+}
+
+func parseModelRules(t reflect.Type) []*fieldRule {
+	var rules []*fieldRule
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tagName, _, skip := modelFieldTag(field)
+		if skip {
+			continue
+		}
+		if isEmbeddedModel(field, tagName) {
+			rules = append(rules, &fieldRule{index: i, nested: true, length: -1})
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		var name = modelFieldName(field)
+		var rule = &fieldRule{index: i, name: name, length: -1}
+		var fieldType = field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		rule.nested = fieldType.Kind() == reflect.Struct && !isTextType(fieldType)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || len(tag) == 0 {
+			if rule.nested {
+				rules = append(rules, rule)
+			}
+			continue
+		}
+		for len(tag) > 0 {
+			var item string
+			if strings.HasPrefix(tag, "pattern=") {
+				item, tag = tag, ""
+			} else if index := strings.IndexByte(tag, ','); index >= 0 {
+				item, tag = tag[:index], tag[index+1:]
+			} else {
+				item, tag = tag, ""
+			}
+			parseFieldRule(t, field, rule, strings.TrimSpace(item))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func parseFieldRule(t reflect.Type, field reflect.StructField, rule *fieldRule, item string) {
+	var key, value = item, ""
+	if index := strings.IndexByte(item, '='); index >= 0 {
+		key, value = item[:index], item[index+1:]
+	}
+	var parseNumber = func() *float64 {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			panic(errValidateTag(t, field.Name, item))
+		}
+		return &f
+	}
+	switch key {
+	case "":
+	case "required":
+		rule.required = true
+	case "min":
+		rule.min = parseNumber()
+	case "max":
+		rule.max = parseNumber()
+	case "len":
+		l, err := strconv.Atoi(value)
+		if err != nil || l < 0 {
+			panic(errValidateTag(t, field.Name, item))
+		}
+		rule.length = l
+	case "pattern":
+		reg, err := regexp.Compile(value)
+		if err != nil {
+			panic(errValidateTag(t, field.Name, item))
+		}
+		rule.pattern = reg
+	case "email":
+		rule.email = true
+	case "oneof":
+		rule.oneOf = strings.Fields(value)
+	default:
+		panic(errValidateTag(t, field.Name, item))
+	}
+}
+
+// Validate validate the model by the 'validate' tags of the fields and get the field errors.
+// The rules are 'required', 'min', 'max', 'len', 'pattern', 'email' and 'oneof'. The 'min' and 'max' rules check the value
+// of the number fields, and the length of the string, slice and map fields. The other rules are not checked if the field
+// is not required and has the zero value. The nested struct fields are validated too, and the field name of them is
+// joined by '.', like 'Address.City'. The fields of the embedded structs are validated as the fields of the outer struct.
+// Validate panics if the 'validate' tag is invalid
+func Validate(model interface{}) []*FieldError {
+	if model == nil {
+		return nil
+	}
+	var mValue = reflect.ValueOf(model)
+	for mValue.Kind() == reflect.Ptr {
+		if mValue.IsNil() {
+			return nil
+		}
+		mValue = mValue.Elem()
+	}
+	if mValue.Kind() != reflect.Struct {
+		return nil
+	}
+	return validateModel(mValue, "", nil)
+}
+
+func validateModel(mValue reflect.Value, prefix string, errs []*FieldError) []*FieldError {
+	for _, rule := range getModelRules(mValue.Type()) {
+		var name = strAdd(prefix, rule.name)
+		var field = mValue.Field(rule.index)
+		if msg := rule.check(field); len(msg) > 0 {
+			errs = append(errs, &FieldError{Field: name, Message: msg})
+			continue
+		}
+		if rule.nested {
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					continue
+				}
+				field = field.Elem()
+			}
+			if len(rule.name) > 0 {
+				name = strAdd(name, ".")
+			}
+			errs = validateModel(field, name, errs)
+		}
+	}
+	return errs
+}
+
+// check check the field value with the rule and get the error message
+func (rule *fieldRule) check(field reflect.Value) string {
+	if isZeroValue(field) {
+		if rule.required {
+			return "is required"
+		}
+		return ""
+	}
+	for field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface {
+		field = field.Elem()
+	}
+	var isNumber = true
+	var number float64
+	var length int
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = field.Float()
+	case reflect.String:
+		isNumber = false
+		length = len([]rune(field.String()))
+	case reflect.Slice, reflect.Array, reflect.Map:
+		isNumber = false
+		length = field.Len()
+	default:
+		return ""
+	}
+	if isNumber {
+		if rule.min != nil && number < *rule.min {
+			return strAdd("must be at least ", formatNumber(*rule.min))
+		}
+		if rule.max != nil && number > *rule.max {
+			return strAdd("must be at most ", formatNumber(*rule.max))
+		}
+	} else {
+		if rule.min != nil && float64(length) < *rule.min {
+			return strAdd("length must be at least ", formatNumber(*rule.min))
+		}
+		if rule.max != nil && float64(length) > *rule.max {
+			return strAdd("length must be at most ", formatNumber(*rule.max))
+		}
+		if rule.length >= 0 && length != rule.length {
+			return strAdd("length must be ", strconv.Itoa(rule.length))
+		}
+	}
+	var str string
+	if field.Kind() == reflect.String {
+		str = field.String()
+	} else if rule.pattern != nil || rule.email || len(rule.oneOf) > 0 {
+		str = valueString(field, isNumber, number)
+	}
+	if rule.pattern != nil && !rule.pattern.MatchString(str) {
+		return "is in an invalid format"
+	}
+	if rule.email && !emailReg.MatchString(str) {
+		return "is not a valid email address"
+	}
+	if len(rule.oneOf) > 0 {
+		for _, v := range rule.oneOf {
+			if v == str {
+				return ""
+			}
+		}
+		return strAdd("must be one of ", strings.Join(rule.oneOf, ", "))
+	}
+	return ""
+}
+
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func valueString(v reflect.Value, isNumber bool, number float64) string {
+	if !isNumber {
+		return ""
+	}
+	if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+		return formatNumber(number)
+	}
+	if v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+	return strconv.FormatInt(v.Int(), 10)
+}
+
+// ModelState the field errors of the models in the current request
+type ModelState struct {
+	fields []string
+	errors map[string][]string
+}
+
+// AddError add the error message of the field
+func (ms *ModelState) AddError(field, msg string) {
+	if ms.errors == nil {
+		ms.errors = make(map[string][]string)
+	}
+	if _, ok := ms.errors[field]; !ok {
+		ms.fields = append(ms.fields, field)
+	}
+	ms.errors[field] = append(ms.errors[field], msg)
+}
+
+// AddErrors add the field errors
+func (ms *ModelState) AddErrors(errs []*FieldError) {
+	for _, err := range errs {
+		ms.AddError(err.Field, err.Message)
+	}
+}
+
+// IsValid check there is no field error
+func (ms *ModelState) IsValid() bool {
+	return ms == nil || len(ms.fields) == 0
+}
+
+// Errors get the error messages of the field
+func (ms *ModelState) Errors(field string) []string {
+	if ms == nil {
+		return nil
+	}
+	return ms.errors[field]
+}
+
+// Error get the first error message of the field
+func (ms *ModelState) Error(field string) string {
+	if errs := ms.Errors(field); len(errs) > 0 {
+		return errs[0]
+	}
+	return ""
+}
+
+// FieldErrors get all the field errors in the order of the fields being added
+func (ms *ModelState) FieldErrors() []*FieldError {
+	if ms == nil {
+		return nil
+	}
+	var errs []*FieldError
+	for _, field := range ms.fields {
+		for _, msg := range ms.errors[field] {
+			errs = append(errs, &FieldError{Field: field, Message: msg})
+		}
+	}
+	return errs
+}
+
+// Clear remove all the field errors
+func (ms *ModelState) Clear() {
+	ms.fields = nil
+	ms.errors = nil
+}
@@ -0,0 +1,59 @@
+package wemvc
+
+import (
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `validate:"len=6,pattern=^[0-9,]+$"`
+}
+
+type testValidateModel struct {
+	Name    string       `json:"name" validate:"required,min=2,max=8"`
+	Age     int          `validate:"min=18,max=99"`
+	Email   string       `validate:"email"`
+	Color   string       `validate:"oneof=red green blue"`
+	Tags    []string     `validate:"max=2"`
+	Address *testAddress `json:"address"`
+}
+
+func Test_Validate(t *testing.T) {
+	var m = &testValidateModel{Name: "steve", Age: 20, Email: "steve@example.com", Color: "red"}
+	if errs := Validate(m); len(errs) != 0 {
+		t.Error("test 1 failed", errs[0].Field, errs[0].Message)
+	}
+	m = &testValidateModel{Age: 10, Email: "steve", Color: "pink", Tags: []string{"a", "b", "c"}, Address: &testAddress{Zip: "12,45"}}
+	var errs = Validate(m)
+	var expected = []string{"name", "Age", "Email", "Color", "Tags", "address.city", "address.Zip"}
+	if len(errs) != len(expected) {
+		t.Fatal("test 2 failed", len(errs))
+	}
+	for i, err := range errs {
+		if err.Field != expected[i] {
+			t.Error("test 2 failed", i, err.Field, err.Message)
+		}
+	}
+	if errs = Validate(&testValidateModel{Name: "s"}); len(errs) != 1 || errs[0].Message != "length must be at least 2" {
+		t.Error("test 3 failed", errs)
+	}
+
+	var ms = &ModelState{}
+	ms.AddErrors(Validate(m))
+	ms.AddError("name", "is taken")
+	if ms.IsValid() || len(ms.Errors("name")) != 2 || ms.Error("Age") != "must be at least 18" || len(ms.FieldErrors()) != 8 {
+		t.Error("test 4 failed")
+	}
+	if field_error_view(ms, "name") != "is required" || len(field_errors_view(nil, "name")) != 0 {
+		t.Error("test 5 failed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("test 6 failed")
+		}
+	}()
+	Validate(&struct {
+		Name string `validate:"unknown"`
+	}{})
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 )
 
-func include_view(path string, ctx map[string]interface{}) interface{} {
+func (app *Application) includeView(path string, ctx map[string]interface{}) interface{} {
 	var ns *NsSection
 	if ctx != nil {
 		if nsInterface, ok := ctx["Namespace"]; ok && nsInterface != nil {
@@ -16,9 +16,9 @@ func include_view(path string, ctx map[string]interface{}) interface{} {
 		}
 	}
 	if ns == nil {
-		bytes, err :=  RenderView(path, ctx)
+		bytes, err := app.renderView(path, ctx)
 		if err == nil {
-			return  template.HTML(bytes)
+			return template.HTML(bytes)
 		}
 		panic(err)
 	} else {
@@ -30,6 +30,16 @@ func include_view(path string, ctx map[string]interface{}) interface{} {
 	}
 }
 
+// urlView generate the URL of the named route, the route params are set in key-value pairs:
+// {{url "user-detail" "id" "12"}}
+func (app *Application) urlView(name string, pairs ...string) (string, error) {
+	var params = make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		params[pairs[i]] = pairs[i+1]
+	}
+	return app.urlFor(name, params)
+}
+
 func req_query(req *http.Request, key string) string {
 	if req == nil || len(key) == 0 {
 		return ""
@@ -77,4 +87,15 @@ func session_view(session SessionStore, key interface{}) interface{} {
 		return nil
 	}
 	return session.Get(key)
-}
\ No newline at end of file
+}
+
+// field_error_view get the first error message of the model field: {{field_error .ModelState "email"}}
+func field_error_view(ms *ModelState, field string) string {
+	return ms.Error(field)
+}
+
+// field_errors_view get the error messages of the model field:
+// {{range field_errors .ModelState "email"}}<span>{{.}}</span>{{end}}
+func field_errors_view(ms *ModelState, field string) []string {
+	return ms.Errors(field)
+}